@@ -1,8 +1,17 @@
 package ragu
 
 import (
+	"fmt"
+	"sort"
+	"sync"
+
 	"github.com/kralicky/ragu/pkg/plugins/golang"
+	"github.com/kralicky/ragu/pkg/plugins/golang/connect"
+	"github.com/kralicky/ragu/pkg/plugins/golang/gateway"
 	"github.com/kralicky/ragu/pkg/plugins/golang/grpc"
+	"github.com/kralicky/ragu/pkg/plugins/golang/restclient"
+	"github.com/kralicky/ragu/pkg/plugins/golang/twirp"
+	"github.com/kralicky/ragu/pkg/plugins/openapiv3"
 	"github.com/kralicky/ragu/pkg/plugins/python"
 	"google.golang.org/protobuf/compiler/protogen"
 )
@@ -12,17 +21,91 @@ type Generator interface {
 	Generate(gen *protogen.Plugin) error
 }
 
+// ParameterizedGenerator is implemented by generators that accept a
+// protoc-style parameter string, such as "paths=source_relative" or
+// "Mfoo/foo.proto=example.com/foo". Generators with different parameters are
+// run against separate plugin instances, so their options do not interfere
+// with each other.
+type ParameterizedGenerator interface {
+	Generator
+	Parameter() string
+}
+
+// DefaultGenerators returns the go, go-grpc, and go-grpc-gateway generators.
 func DefaultGenerators() []Generator {
 	return []Generator{
 		golang.Generator,
 		grpc.Generator,
+		gateway.Generator,
 	}
 }
 
+// AllGenerators returns all built-in generators.
 func AllGenerators() []Generator {
 	return []Generator{
 		golang.Generator,
 		grpc.Generator,
+		grpc.FakeGenerator,
+		grpc.HarnessGenerator,
+		gateway.Generator,
+		connect.Generator,
+		twirp.Generator,
+		restclient.Generator,
+		openapiv3.Generator,
 		python.Generator,
 	}
 }
+
+var (
+	registryMu sync.RWMutex
+	registry   = map[string]Generator{}
+)
+
+func init() {
+	for _, g := range AllGenerators() {
+		RegisterGenerator(g)
+	}
+}
+
+// RegisterGenerator adds a generator to the registry, making it available
+// by name from GeneratorByName and GeneratorsByName. Registering a generator
+// with the same name as an existing one replaces it.
+func RegisterGenerator(g Generator) {
+	registryMu.Lock()
+	defer registryMu.Unlock()
+	registry[g.Name()] = g
+}
+
+// GeneratorByName looks up a registered generator by name (e.g. "go-grpc").
+func GeneratorByName(name string) (Generator, bool) {
+	registryMu.RLock()
+	defer registryMu.RUnlock()
+	g, ok := registry[name]
+	return g, ok
+}
+
+// GeneratorsByName looks up several registered generators by name, returning
+// an error if any of them are not found.
+func GeneratorsByName(names ...string) ([]Generator, error) {
+	generators := make([]Generator, 0, len(names))
+	for _, name := range names {
+		g, ok := GeneratorByName(name)
+		if !ok {
+			return nil, fmt.Errorf("unknown generator %q (available generators: %v)", name, GeneratorNames())
+		}
+		generators = append(generators, g)
+	}
+	return generators, nil
+}
+
+// GeneratorNames returns the sorted names of all registered generators.
+func GeneratorNames() []string {
+	registryMu.RLock()
+	defer registryMu.RUnlock()
+	names := make([]string, 0, len(registry))
+	for name := range registry {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
@@ -0,0 +1,165 @@
+// Package gentest builds and runs generated Go code in temporary modules, for
+// tests that exercise the behavior of the generated code.
+package gentest
+
+import (
+	"os"
+	"os/exec"
+	"path"
+	"path/filepath"
+	"runtime"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/kralicky/ragu"
+	"golang.org/x/mod/modfile"
+)
+
+// ModulePath is the module path of the temporary modules, which matches the
+// go_package options of the protos in testdata.
+const ModulePath = "github.com/kralicky/ragu"
+
+// Module is a temporary Go module containing generated code.
+type Module struct {
+	Dir string
+
+	t    testing.TB
+	pkgs map[string]struct{}
+}
+
+// NewModule creates a temporary module that requires the same dependency
+// versions as ragu itself. Additional requirements, in the form path@version,
+// replace those versions. Since the module is built with the go command, the
+// test is skipped in short mode.
+func NewModule(t testing.TB, requires ...string) *Module {
+	t.Helper()
+	if testing.Short() {
+		t.Skip("building generated code is skipped in short mode")
+	}
+	root := repoRoot()
+	data, err := os.ReadFile(filepath.Join(root, "go.mod"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	mod, err := modfile.Parse("go.mod", data, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	f := &modfile.File{}
+	if err := f.AddModuleStmt(ModulePath); err != nil {
+		t.Fatal(err)
+	}
+	if err := f.AddGoStmt(mod.Go.Version); err != nil {
+		t.Fatal(err)
+	}
+	for _, r := range mod.Require {
+		if err := f.AddRequire(r.Mod.Path, r.Mod.Version); err != nil {
+			t.Fatal(err)
+		}
+	}
+	for _, r := range requires {
+		p, v, _ := strings.Cut(r, "@")
+		if err := f.AddRequire(p, v); err != nil {
+			t.Fatal(err)
+		}
+	}
+	f.Cleanup()
+	out, err := f.Format()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	m := &Module{Dir: t.TempDir(), t: t, pkgs: map[string]struct{}{}}
+	m.WriteFile("go.mod", string(out))
+	if sum, err := os.ReadFile(filepath.Join(root, "go.sum")); err == nil {
+		m.WriteFile("go.sum", string(sum))
+	}
+	return m
+}
+
+// WriteGenerated writes the generated Go files into the module, at the
+// locations given by their import paths. Other files are ignored.
+func (m *Module) WriteGenerated(files []*ragu.GeneratedFile) {
+	m.t.Helper()
+	for _, f := range files {
+		if path.Ext(f.Name) != ".go" {
+			continue
+		}
+		rel, ok := strings.CutPrefix(f.Package, ModulePath+"/")
+		if !ok {
+			m.t.Fatalf("generated package %s is outside of module %s", f.Package, ModulePath)
+		}
+		m.WriteFile(path.Join(rel, f.Name), f.Content)
+		m.pkgs["./"+rel] = struct{}{}
+	}
+}
+
+// WriteFile writes a file into the module, relative to its root.
+func (m *Module) WriteFile(name, content string) {
+	m.t.Helper()
+	filename := filepath.Join(m.Dir, filepath.FromSlash(name))
+	if err := os.MkdirAll(filepath.Dir(filename), 0o755); err != nil {
+		m.t.Fatal(err)
+	}
+	if err := os.WriteFile(filename, []byte(content), 0o644); err != nil {
+		m.t.Fatal(err)
+	}
+}
+
+// CopyPackage copies the Go files of a package in this repository (e.g.
+// "pkg/swaggerui") into the module, for generated code that imports it.
+func (m *Module) CopyPackage(dir string) {
+	m.t.Helper()
+	matches, err := filepath.Glob(filepath.Join(repoRoot(), filepath.FromSlash(dir), "*.go"))
+	if err != nil {
+		m.t.Fatal(err)
+	}
+	for _, filename := range matches {
+		if strings.HasSuffix(filename, "_test.go") {
+			continue
+		}
+		data, err := os.ReadFile(filename)
+		if err != nil {
+			m.t.Fatal(err)
+		}
+		m.WriteFile(path.Join(dir, filepath.Base(filename)), string(data))
+	}
+}
+
+// Build vets the generated packages. Missing dependencies are resolved by the
+// go command; "go mod tidy" cannot be used, since it ignores testdata
+// directories.
+func (m *Module) Build() {
+	m.t.Helper()
+	pkgs := make([]string, 0, len(m.pkgs))
+	for pkg := range m.pkgs {
+		pkgs = append(pkgs, pkg)
+	}
+	sort.Strings(pkgs)
+	m.Go(append([]string{"vet"}, pkgs...)...)
+}
+
+// Test builds the module, then runs the tests in the given packages, e.g.
+// "./testdata/grpc1".
+func (m *Module) Test(pkgs ...string) {
+	m.t.Helper()
+	m.Build()
+	m.Go(append([]string{"test", "-count=1"}, pkgs...)...)
+}
+
+// Go runs the go command in the module, failing the test on error.
+func (m *Module) Go(args ...string) {
+	m.t.Helper()
+	cmd := exec.Command("go", args...)
+	cmd.Dir = m.Dir
+	cmd.Env = append(os.Environ(), "GOFLAGS=-mod=mod")
+	if out, err := cmd.CombinedOutput(); err != nil {
+		m.t.Fatalf("go %s: %v\n%s", strings.Join(args, " "), err, out)
+	}
+}
+
+func repoRoot() string {
+	_, file, _, _ := runtime.Caller(0)
+	return filepath.Join(filepath.Dir(file), "..", "..")
+}
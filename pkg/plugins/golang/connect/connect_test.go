@@ -0,0 +1,201 @@
+package connect_test
+
+import (
+	"testing"
+
+	"github.com/kralicky/ragu"
+	"github.com/kralicky/ragu/internal/gentest"
+	"github.com/kralicky/ragu/pkg/plugins/golang"
+	"github.com/kralicky/ragu/pkg/plugins/golang/connect"
+)
+
+// The oldest version of connect-go that satisfies the IsAtLeastVersion1_13_0
+// assertion in the generated code.
+const connectVersion = "connectrpc.com/connect@v1.13.0"
+
+func TestConnect(t *testing.T) {
+	out, err := ragu.GenerateCode([]ragu.Generator{
+		golang.Generator,
+		connect.Generator,
+	}, "../../../../testdata/stream1/*.proto")
+	if err != nil {
+		t.Fatal(err)
+	}
+	m := gentest.NewModule(t, connectVersion)
+	m.WriteGenerated(out)
+	m.WriteFile("testdata/stream1/stream1connect/connect_test.go", connectTest)
+	m.Test("./testdata/stream1/stream1connect")
+}
+
+func TestPackageSuffix(t *testing.T) {
+	out, err := ragu.GenerateCode([]ragu.Generator{
+		golang.Generator,
+		connect.NewGenerator(connect.Options{Opt: "package_suffix=rpc"}),
+	}, "../../../../testdata/stream1/*.proto")
+	if err != nil {
+		t.Fatal(err)
+	}
+	m := gentest.NewModule(t, connectVersion)
+	m.WriteGenerated(out)
+	m.WriteFile("testdata/stream1/stream1rpc/rpc_test.go", `package stream1rpc
+
+var _ StreamerHandler = UnimplementedStreamerHandler{}
+`)
+	m.Test("./testdata/stream1/stream1rpc")
+}
+
+// connectTest calls each kind of method through a connect handler, with each
+// of the protocols supported by connect-go.
+const connectTest = `package stream1connect_test
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"connectrpc.com/connect"
+	"github.com/kralicky/ragu/testdata/stream1"
+	"github.com/kralicky/ragu/testdata/stream1/stream1connect"
+)
+
+type handler struct {
+	stream1connect.UnimplementedStreamerHandler
+}
+
+func (handler) Unary(_ context.Context, req *connect.Request[stream1.Item]) (*connect.Response[stream1.Item], error) {
+	if req.Msg.Name == "missing" {
+		return nil, connect.NewError(connect.CodeNotFound, errors.New("item not found"))
+	}
+	return connect.NewResponse(&stream1.Item{Name: req.Msg.Name, Count: req.Msg.Count + 1}), nil
+}
+
+func (handler) ServerStream(_ context.Context, req *connect.Request[stream1.Item], stream *connect.ServerStream[stream1.Item]) error {
+	for i := int32(0); i < req.Msg.Count; i++ {
+		if err := stream.Send(&stream1.Item{Name: req.Msg.Name, Count: i}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (handler) ClientStream(_ context.Context, stream *connect.ClientStream[stream1.Item]) (*connect.Response[stream1.Item], error) {
+	total := &stream1.Item{}
+	for stream.Receive() {
+		total.Name += stream.Msg().Name
+		total.Count += stream.Msg().Count
+	}
+	if err := stream.Err(); err != nil {
+		return nil, err
+	}
+	return connect.NewResponse(total), nil
+}
+
+func (handler) BidiStream(_ context.Context, stream *connect.BidiStream[stream1.Item, stream1.Item]) error {
+	for {
+		in, err := stream.Receive()
+		if errors.Is(err, io.EOF) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		if err := stream.Send(in); err != nil {
+			return err
+		}
+	}
+}
+
+func TestConnect(t *testing.T) {
+	mux := http.NewServeMux()
+	path, h := stream1connect.NewStreamerHandler(handler{})
+	if path != "/stream1.Streamer/" {
+		t.Fatalf("unexpected handler path %q", path)
+	}
+	mux.Handle(path, h)
+	srv := httptest.NewUnstartedServer(mux)
+	srv.EnableHTTP2 = true
+	srv.StartTLS()
+	defer srv.Close()
+
+	for name, opts := range map[string][]connect.ClientOption{
+		"connect":  nil,
+		"grpc":     {connect.WithGRPC()},
+		"grpc-web": {connect.WithGRPCWeb()},
+	} {
+		t.Run(name, func(t *testing.T) {
+			testClient(t, stream1connect.NewStreamerClient(srv.Client(), srv.URL, opts...))
+		})
+	}
+}
+
+func testClient(t *testing.T, client stream1connect.StreamerClient) {
+	ctx := context.Background()
+
+	resp, err := client.Unary(ctx, connect.NewRequest(&stream1.Item{Name: "a", Count: 1}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp.Msg.Name != "a" || resp.Msg.Count != 2 {
+		t.Fatalf("Unary: unexpected response %v", resp.Msg)
+	}
+	_, err = client.Unary(ctx, connect.NewRequest(&stream1.Item{Name: "missing"}))
+	if connect.CodeOf(err) != connect.CodeNotFound {
+		t.Fatalf("Unary: expected NotFound, got %v", err)
+	}
+
+	ss, err := client.ServerStream(ctx, connect.NewRequest(&stream1.Item{Name: "a", Count: 3}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var n int32
+	for ; ss.Receive(); n++ {
+		if ss.Msg().Count != n {
+			t.Fatalf("ServerStream: expected count %d, got %d", n, ss.Msg().Count)
+		}
+	}
+	if err := ss.Err(); err != nil {
+		t.Fatal(err)
+	}
+	if n != 3 {
+		t.Fatalf("ServerStream: expected 3 messages, got %d", n)
+	}
+
+	cs := client.ClientStream(ctx)
+	for _, name := range []string{"a", "b"} {
+		if err := cs.Send(&stream1.Item{Name: name, Count: 1}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	total, err := cs.CloseAndReceive()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if total.Msg.Name != "ab" || total.Msg.Count != 2 {
+		t.Fatalf("ClientStream: unexpected response %v", total.Msg)
+	}
+
+	bs := client.BidiStream(ctx)
+	if err := bs.Send(&stream1.Item{Name: "a"}); err != nil {
+		t.Fatal(err)
+	}
+	if err := bs.CloseRequest(); err != nil {
+		t.Fatal(err)
+	}
+	echo, err := bs.Receive()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if echo.Name != "a" {
+		t.Fatalf("BidiStream: unexpected message %v", echo)
+	}
+	if _, err := bs.Receive(); !errors.Is(err, io.EOF) {
+		t.Fatalf("BidiStream: expected io.EOF, got %v", err)
+	}
+	if err := bs.CloseResponse(); err != nil {
+		t.Fatal(err)
+	}
+}
+`
@@ -0,0 +1,88 @@
+// Package connect implements the connect-go generator, which generates
+// Connect handlers and clients equivalent to the output of
+// protoc-gen-connect-go.
+package connect
+
+import (
+	"fmt"
+	"go/token"
+
+	"github.com/kralicky/ragu/pkg/util"
+	"google.golang.org/protobuf/compiler/protogen"
+)
+
+var Generator = generator{}
+
+type Options struct {
+	// Opt is a protoc-style parameter string. In addition to the standard
+	// protogen parameters, "package_suffix=<suffix>" is accepted.
+	// Parameters in Opt take precedence over the fields below.
+	Opt string
+	// PackageSuffix is appended to the Go package name of each proto file to
+	// form the name of the subpackage containing the generated code. If set
+	// to an empty string, the code is generated into the same package as the
+	// messages; the client names then conflict with the go-grpc generator.
+	// Defaults to "connect".
+	PackageSuffix *string
+}
+
+// NewGenerator returns a connect-go generator with the given options.
+func NewGenerator(opts Options) generator {
+	return generator{Options: opts}
+}
+
+type generator struct {
+	Options
+}
+
+func (generator) Name() string {
+	return "connect-go"
+}
+
+func (g generator) Parameter() string {
+	return g.Opt
+}
+
+func (g generator) Generate(gen *protogen.Plugin) error {
+	cfg, err := g.config(gen.Request.GetParameter())
+	if err != nil {
+		return err
+	}
+	for _, f := range gen.Files {
+		if f.Generate {
+			generateFile(gen, f, cfg)
+		}
+	}
+	return nil
+}
+
+// config holds the settings for a single call to Generate.
+type config struct {
+	packageSuffix string
+}
+
+func (g generator) config(param string) (config, error) {
+	cfg := config{
+		packageSuffix: "connect",
+	}
+	if g.PackageSuffix != nil {
+		cfg.packageSuffix = *g.PackageSuffix
+	}
+	if err := util.ParsePluginParams(param, cfg.set); err != nil {
+		return config{}, err
+	}
+	if !token.IsIdentifier(cfg.packageSuffix) && cfg.packageSuffix != "" {
+		return config{}, fmt.Errorf("connect-go: package suffix %q is not a valid Go identifier", cfg.packageSuffix)
+	}
+	return cfg, nil
+}
+
+func (c *config) set(name, value string) error {
+	switch name {
+	case "package_suffix":
+		c.packageSuffix = value
+	default:
+		return fmt.Errorf("connect-go: unknown parameter %q", name)
+	}
+	return nil
+}
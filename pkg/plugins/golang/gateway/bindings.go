@@ -0,0 +1,41 @@
+package gateway
+
+import (
+	"github.com/kralicky/grpc-gateway/v2/pkg/descriptor"
+	"google.golang.org/protobuf/compiler/protogen"
+)
+
+// RegisterFuncs returns the Register<Service><Suffix> function that the
+// go-grpc-gateway generator, configured with opts, generates for each service
+// with HTTP bindings in the files to generate. Parameters in opts.Opt are
+// used in place of those of the plugin. It is used by other generators to
+// refer to the gateway handlers without generating them.
+func RegisterFuncs(gen *protogen.Plugin, opts Options) (map[*protogen.Service]protogen.GoIdent, error) {
+	cfg, err := generator{Options: opts}.config(opts.Opt)
+	if err != nil {
+		return nil, err
+	}
+	reg := descriptor.NewRegistry()
+	if err := cfg.apply(reg); err != nil {
+		return nil, err
+	}
+	if err := reg.LoadFromPlugin(gen); err != nil {
+		return nil, err
+	}
+	var targets []*descriptor.File
+	for _, name := range gen.Request.FileToGenerate {
+		f, err := reg.LookupFile(name)
+		if err != nil {
+			return nil, err
+		}
+		targets = append(targets, f)
+	}
+	funcs := map[*protogen.Service]protogen.GoIdent{}
+	for _, pkg := range collectGatewayPackages(gen, targets) {
+		_, importPath := cfg.outputLocation(pkg.file.GeneratedFilenamePrefix, pkg.file.GoImportPath)
+		for _, svc := range pkg.services {
+			funcs[svc] = importPath.Ident("Register" + svc.GoName + cfg.registerFuncSuffix)
+		}
+	}
+	return funcs, nil
+}
@@ -0,0 +1,109 @@
+package gateway
+
+import (
+	"path"
+	"strconv"
+	"strings"
+
+	"github.com/iancoleman/strcase"
+	"github.com/kralicky/grpc-gateway/v2/pkg/descriptor"
+	"google.golang.org/protobuf/compiler/protogen"
+)
+
+const (
+	httpPackage      = protogen.GoImportPath("net/http")
+	stringsPackage   = protogen.GoImportPath("strings")
+	runtimePackage   = protogen.GoImportPath("github.com/kralicky/grpc-gateway/v2/runtime")
+	swaggeruiPackage = protogen.GoImportPath("github.com/kralicky/ragu/pkg/swaggerui")
+)
+
+// generateEmbeddedSpecs generates a .pb.swagger.go file for each OpenAPI
+// definition, containing the definition and handlers to serve it.
+func generateEmbeddedSpecs(gen *protogen.Plugin, targets []*descriptor.File, specs []*descriptor.ResponseFile, cfg config) {
+	specsByName := map[string]*descriptor.ResponseFile{}
+	for _, spec := range specs {
+		specsByName[spec.GetName()] = spec
+	}
+	for _, target := range targets {
+		specName := strings.TrimSuffix(target.GetName(), path.Ext(target.GetName())) + ".swagger." + string(cfg.openAPIFormat)
+		spec, ok := specsByName[specName]
+		if !ok {
+			continue
+		}
+		file := gen.FilesByPath[target.GetName()]
+		g := gen.NewGeneratedFile(cfg.outputLocation(file.GeneratedFilenamePrefix+".pb.swagger.go", file.GoImportPath))
+		genEmbeddedSpec(g, file, path.Base(specName), spec.GetContent(), cfg)
+	}
+}
+
+func genEmbeddedSpec(g *protogen.GeneratedFile, file *protogen.File, specName, content string, cfg config) {
+	prefix := strcase.ToCamel(strings.TrimSuffix(path.Base(file.Desc.Path()), ".proto"))
+	specVar := prefix + "OpenAPISpec"
+
+	g.P("// Code generated by protoc-gen-grpc-gateway. DO NOT EDIT.")
+	g.P("// source: ", file.Desc.Path())
+	g.P()
+	g.P("package ", cfg.packageName(file.GoPackageName))
+	g.P()
+	g.P("// ", specVar, " contains the OpenAPI definitions generated from")
+	g.P("// ", path.Base(file.Desc.Path()), " (", specName, ").")
+	g.P("var ", specVar, " = []byte(", goStringLiteral(content), ")")
+	g.P()
+	if cfg.swaggerUI {
+		g.P("// New", prefix, "OpenAPIHandler returns an http.Handler which serves ", specVar)
+		g.P("// as ", specName, ", along with a Swagger UI page for paths ending in \"/\".")
+		g.P("func New", prefix, "OpenAPIHandler() ", httpPackage.Ident("Handler"), " {")
+		g.P("return ", swaggeruiPackage.Ident("Handler"), "(", specVar, ", ", strconv.Quote(specName), ")")
+		g.P("}")
+		g.P()
+		g.P("// Register", prefix, "OpenAPIHandler registers the handler returned by")
+		g.P("// New", prefix, "OpenAPIHandler with the gateway mux under the given path")
+		g.P("// prefix (e.g. \"/docs\"), serving the Swagger UI at \"<prefix>/\" and the")
+		g.P("// definitions at \"<prefix>/", specName, "\". Requests for the prefix itself")
+		g.P("// are redirected to \"<prefix>/\".")
+		g.P("func Register", prefix, "OpenAPIHandler(mux *", runtimePackage.Ident("ServeMux"), ", prefix string) error {")
+		g.P("h := New", prefix, "OpenAPIHandler()")
+		g.P("handler := func(w ", httpPackage.Ident("ResponseWriter"), ", r *", httpPackage.Ident("Request"), ", _ map[string]string) {")
+		g.P("h.ServeHTTP(w, r)")
+		g.P("}")
+		g.P("prefix = ", stringsPackage.Ident("TrimSuffix"), `(prefix, "/")`)
+		g.P(`if prefix != "" {`)
+		g.P("if err := mux.HandlePath(", strconv.Quote("GET"), ", prefix, handler); err != nil {")
+		g.P("return err")
+		g.P("}")
+		g.P("}")
+		g.P("return mux.HandlePath(", strconv.Quote("GET"), `, prefix+"/**", handler)`)
+		g.P("}")
+	} else {
+		contentType := "application/json"
+		if cfg.openAPIFormat == "yaml" {
+			contentType = "application/yaml"
+		}
+		g.P("// New", prefix, "OpenAPIHandler returns an http.Handler which serves ", specVar, ".")
+		g.P("func New", prefix, "OpenAPIHandler() ", httpPackage.Ident("Handler"), " {")
+		g.P("return ", httpPackage.Ident("HandlerFunc"), "(func(w ", httpPackage.Ident("ResponseWriter"), ", r *", httpPackage.Ident("Request"), ") {")
+		g.P("w.Header().Set(\"Content-Type\", ", strconv.Quote(contentType), ")")
+		g.P("w.Write(", specVar, ")")
+		g.P("})")
+		g.P("}")
+		g.P()
+		g.P("// Register", prefix, "OpenAPIHandler registers the handler returned by")
+		g.P("// New", prefix, "OpenAPIHandler with the gateway mux at the given path")
+		g.P("// (e.g. \"/", specName, "\").")
+		g.P("func Register", prefix, "OpenAPIHandler(mux *", runtimePackage.Ident("ServeMux"), ", path string) error {")
+		g.P("h := New", prefix, "OpenAPIHandler()")
+		g.P("return mux.HandlePath(", strconv.Quote("GET"), ", path, func(w ", httpPackage.Ident("ResponseWriter"), ", r *", httpPackage.Ident("Request"), ", _ map[string]string) {")
+		g.P("h.ServeHTTP(w, r)")
+		g.P("})")
+		g.P("}")
+	}
+}
+
+// goStringLiteral returns a raw string literal for s if possible, or a
+// quoted string literal otherwise.
+func goStringLiteral(s string) string {
+	if strings.ContainsAny(s, "`\r") {
+		return strconv.Quote(s)
+	}
+	return "`" + s + "`"
+}
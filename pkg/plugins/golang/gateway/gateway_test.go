@@ -0,0 +1,339 @@
+package gateway_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/kralicky/ragu"
+	"github.com/kralicky/ragu/internal/gentest"
+	"github.com/kralicky/ragu/pkg/plugins/golang"
+	"github.com/kralicky/ragu/pkg/plugins/golang/gateway"
+	"github.com/kralicky/ragu/pkg/plugins/golang/grpc"
+	"gopkg.in/yaml.v3"
+)
+
+func TestEmbedOpenAPI(t *testing.T) {
+	out, err := ragu.GenerateCode([]ragu.Generator{
+		golang.Generator,
+		grpc.Generator,
+		gateway.NewGenerator(gateway.Options{
+			GenerateOpenAPI: true,
+			EmbedOpenAPI:    true,
+			SwaggerUI:       true,
+		}),
+	}, "../../../../testdata/grpc1/*.proto")
+	if err != nil {
+		t.Fatal(err)
+	}
+	m := gentest.NewModule(t)
+	m.WriteGenerated(out)
+	m.CopyPackage("pkg/swaggerui")
+	m.WriteFile("testdata/grpc1/swagger_test.go", swaggerTest)
+	m.Test("./testdata/grpc1")
+}
+
+func TestBindingValidation(t *testing.T) {
+	_, err := ragu.GenerateCode([]ragu.Generator{gateway.Generator}, "testdata/invalid/*.proto")
+	if err == nil {
+		t.Fatal("expected invalid bindings to be rejected")
+	}
+	for _, msg := range []string{
+		`invalid.proto:11:5: invalid.A.Get: path variable "id": field "id" does not exist in invalid.Request`,
+		"invalid.A.Get: GET methods cannot have a body",
+		"invalid.B.List: route GET /v1/{name} conflicts with invalid.A.Get",
+	} {
+		if !strings.Contains(err.Error(), msg) {
+			t.Errorf("expected error to contain %q, got:\n%v", msg, err)
+		}
+	}
+}
+
+func TestOpenAPIOptions(t *testing.T) {
+	out, err := ragu.GenerateCode([]ragu.Generator{
+		gateway.NewGenerator(gateway.Options{
+			Opt:           "generate_openapi,allow_merge,merge_file_name=apidocs",
+			OpenAPIFormat: "yaml",
+		}),
+	}, "../../../../testdata/grpc1/*.proto", "../../../../testdata/stream1/*.proto")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var docs []string
+	for _, f := range out {
+		if strings.HasSuffix(f.Name, ".swagger.yaml") || strings.HasSuffix(f.Name, ".swagger.json") {
+			docs = append(docs, f.Name)
+		}
+	}
+	if len(docs) != 1 || docs[0] != "apidocs.swagger.yaml" {
+		t.Fatalf("expected a single merged document, got %v", docs)
+	}
+	var doc struct {
+		Swagger string
+		Paths   map[string]map[string]struct {
+			OperationID string `yaml:"operationId"`
+		}
+	}
+	for _, f := range out {
+		if f.Name == docs[0] {
+			if err := yaml.Unmarshal([]byte(f.Content), &doc); err != nil {
+				t.Fatal(err)
+			}
+		}
+	}
+	if doc.Swagger != "2.0" {
+		t.Fatalf("unexpected swagger version %q", doc.Swagger)
+	}
+	for _, op := range []struct{ method, path, id string }{
+		{"post", "/testing", "Service1_Testing"},
+		{"get", "/v1/items/{name}", "Streamer_Unary"},
+	} {
+		if doc.Paths[op.path][op.method].OperationID != op.id {
+			t.Fatalf("expected %s to be merged into the document, got %v", op.id, doc.Paths)
+		}
+	}
+}
+
+func TestEmbedOpenAPIWithoutDefinitions(t *testing.T) {
+	_, err := ragu.GenerateCode([]ragu.Generator{
+		gateway.NewGenerator(gateway.Options{EmbedOpenAPI: true}),
+	}, "../../../../testdata/grpc1/*.proto")
+	if err == nil || !strings.Contains(err.Error(), "embed_openapi requires generate_openapi") {
+		t.Fatalf("expected an error for embed_openapi without definitions, got %v", err)
+	}
+}
+
+func TestRegisterAll(t *testing.T) {
+	out, err := ragu.GenerateCode([]ragu.Generator{
+		golang.Generator,
+		grpc.Generator,
+		gateway.NewGenerator(gateway.Options{Opt: "register_all"}),
+	}, "../../../../testdata/grpc1/*.proto")
+	if err != nil {
+		t.Fatal(err)
+	}
+	m := gentest.NewModule(t)
+	m.WriteGenerated(out)
+	m.WriteFile("testdata/grpc1/register_all_test.go", registerAllTest)
+	m.Test("./testdata/grpc1")
+}
+
+func TestStandalone(t *testing.T) {
+	out, err := ragu.GenerateCode([]ragu.Generator{
+		golang.Generator,
+		grpc.Generator,
+		gateway.NewGenerator(gateway.Options{
+			Standalone:         true,
+			RegisterFuncSuffix: "Gateway",
+			RegisterAll:        true,
+			GenerateOpenAPI:    true,
+			EmbedOpenAPI:       true,
+		}),
+	}, "../../../../testdata/grpc1/*.proto")
+	if err != nil {
+		t.Fatal(err)
+	}
+	m := gentest.NewModule(t)
+	m.WriteGenerated(out)
+	m.WriteFile("testdata/grpc1/gateway/gateway_test.go", standaloneTest)
+	m.Test("./testdata/grpc1/gateway")
+}
+
+// standaloneTest serves the standalone gateway, which is in its own package
+// named after the subpackage directory, with the register functions renamed.
+const standaloneTest = `package gateway
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/kralicky/grpc-gateway/v2/runtime"
+	"github.com/kralicky/ragu/testdata/grpc1"
+	"google.golang.org/grpc"
+)
+
+type client struct {
+	grpc1.Service1Client
+}
+
+var _ func(context.Context, *runtime.ServeMux, string, []grpc.DialOption) error = RegisterService1GatewayFromEndpoint
+
+func (client) Testing(_ context.Context, in *grpc1.Test, _ ...grpc.CallOption) (*grpc1.Test, error) {
+	return &grpc1.Test{A: in.A, B: in.B + 1}, nil
+}
+
+func TestGateway(t *testing.T) {
+	mux := runtime.NewServeMux()
+	if err := RegisterService1GatewayClient(context.Background(), mux, client{}); err != nil {
+		t.Fatal(err)
+	}
+	if err := RegisterGrpc1OpenAPIHandler(mux, "/grpc_1.swagger.json"); err != nil {
+		t.Fatal(err)
+	}
+	if len(GatewayRoutes) != 1 || GatewayRoutes[0].Pattern != "/testing" {
+		t.Fatalf("unexpected routes: %v", GatewayRoutes)
+	}
+	srv := httptest.NewServer(mux)
+	defer srv.Close()
+
+	resp, err := http.Post(srv.URL+"/testing", "application/json", strings.NewReader(` + "`" + `{"A":"a","B":1}` + "`" + `))
+	if err != nil {
+		t.Fatal(err)
+	}
+	body, _ := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK || !strings.Contains(string(body), ` + "`" + `"B":2` + "`" + `) {
+		t.Fatalf("unexpected response: %d %s", resp.StatusCode, body)
+	}
+
+	resp, err = http.Get(srv.URL + "/grpc_1.swagger.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("unexpected status: %d", resp.StatusCode)
+	}
+}
+`
+
+// registerAllTest registers all handlers of the package with a connection
+// to a grpc server, and calls each route in the route table.
+const registerAllTest = `package grpc1
+
+import (
+	"context"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/kralicky/grpc-gateway/v2/runtime"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/test/bufconn"
+)
+
+type server struct {
+	UnimplementedService1Server
+}
+
+func (server) Testing(_ context.Context, in *Test) (*Test, error) {
+	return &Test{A: in.A, B: in.B + 1}, nil
+}
+
+func TestRegisterAll(t *testing.T) {
+	lis := bufconn.Listen(1024 * 1024)
+	srv := grpc.NewServer()
+	RegisterService1Server(srv, server{})
+	go srv.Serve(lis)
+	defer srv.Stop()
+
+	conn, err := grpc.DialContext(context.Background(), "bufnet",
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) {
+			return lis.DialContext(ctx)
+		}),
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+
+	mux := runtime.NewServeMux()
+	if err := RegisterAllHandlers(context.Background(), mux, conn); err != nil {
+		t.Fatal(err)
+	}
+	gw := httptest.NewServer(mux)
+	defer gw.Close()
+
+	if len(GatewayRoutes) != 1 {
+		t.Fatalf("unexpected routes: %v", GatewayRoutes)
+	}
+	route := GatewayRoutes[0]
+	if route.Method != http.MethodPost || route.Pattern != "/testing" || route.RPC != "/grpc1.Service1/Testing" {
+		t.Fatalf("unexpected route: %+v", route)
+	}
+	req, err := http.NewRequest(route.Method, gw.URL+route.Pattern, strings.NewReader(` + "`" + `{"A":"a","B":1}` + "`" + `))
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	body, _ := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK || !strings.Contains(string(body), ` + "`" + `"B":2` + "`" + `) {
+		t.Fatalf("unexpected response: %d %s", resp.StatusCode, body)
+	}
+}
+`
+
+// swaggerTest requests the Swagger UI page and definitions from a gateway mux.
+const swaggerTest = `package grpc1
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/kralicky/grpc-gateway/v2/runtime"
+)
+
+func TestSwaggerUI(t *testing.T) {
+	mux := runtime.NewServeMux()
+	if err := RegisterGrpc1OpenAPIHandler(mux, "/docs"); err != nil {
+		t.Fatal(err)
+	}
+	srv := httptest.NewServer(mux)
+	defer srv.Close()
+	client := &http.Client{
+		CheckRedirect: func(*http.Request, []*http.Request) error {
+			return http.ErrUseLastResponse
+		},
+	}
+
+	resp, err := client.Get(srv.URL + "/docs")
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusMovedPermanently || resp.Header.Get("Location") != "/docs/" {
+		t.Fatalf("expected a redirect to /docs/, got %d %q", resp.StatusCode, resp.Header.Get("Location"))
+	}
+
+	resp, err = client.Get(srv.URL + "/docs/")
+	if err != nil {
+		t.Fatal(err)
+	}
+	body, _ := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK || !strings.Contains(string(body), "SwaggerUIBundle") {
+		t.Fatalf("expected the Swagger UI page, got %d:\n%s", resp.StatusCode, body)
+	}
+
+	resp, err = client.Get(srv.URL + "/docs/grpc_1.swagger.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	var spec struct {
+		Paths map[string]any
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&spec); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := spec.Paths["/testing"]; !ok {
+		t.Fatalf("unexpected definitions: %v", spec.Paths)
+	}
+}
+`
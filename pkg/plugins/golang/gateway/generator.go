@@ -2,7 +2,11 @@ package gateway
 
 import (
 	"fmt"
+	"go/token"
+	"path"
 	"strings"
+	"unicode"
+	"unicode/utf8"
 
 	"github.com/kralicky/grpc-gateway/v2/pkg/codegenerator"
 	"github.com/kralicky/grpc-gateway/v2/pkg/descriptor"
@@ -15,18 +19,40 @@ import (
 
 var Generator = generator{}
 
-type generator struct{}
+func NewGenerator(opts Options) generator {
+	return generator{Options: opts}
+}
+
+type generator struct {
+	Options
+}
 
 func (generator) Name() string {
 	return "go-grpc-gateway"
 }
 
-func (generator) Generate(gen *protogen.Plugin) error {
+func (g generator) Parameter() string {
+	return g.Opt
+}
+
+func (g generator) Generate(gen *protogen.Plugin) error {
+	cfg, err := g.config(gen.Request.GetParameter())
+	if err != nil {
+		return err
+	}
+
 	reg := descriptor.NewRegistry()
+	if err := cfg.apply(reg); err != nil {
+		return err
+	}
 
 	codegenerator.SetSupportedFeaturesOnPluginGen(gen)
 
-	generator := gengateway.New(reg, true, "Handler", false, false)
+	generator := gengateway.New(reg, cfg.useRequestContext, cfg.registerFuncSuffix, cfg.allowPatchFeature, cfg.standalone)
+
+	if err := validateBindings(gen, reg, cfg.allowDeleteBody); err != nil {
+		return err
+	}
 
 	if err := reg.LoadFromPlugin(gen); err != nil {
 		return err
@@ -46,10 +72,18 @@ func (generator) Generate(gen *protogen.Plugin) error {
 			return err
 		}
 		gatewayTargets = append(gatewayTargets, f)
-		if proto.HasExtension(f.GetOptions(), options.E_Openapiv2Swagger) {
+		if proto.HasExtension(f.GetOptions(), options.E_Openapiv2Swagger) || (cfg.generateOpenAPI && hasBindings(f)) {
 			openapiTargets = append(openapiTargets, f)
 		}
 	}
+	if cfg.embedOpenAPI && !cfg.generateOpenAPI && len(openapiTargets) == 0 {
+		return fmt.Errorf("go-grpc-gateway: embed_openapi requires generate_openapi, or the openapiv2_swagger option in at least one file")
+	}
+
+	var gatewayPackages []*gatewayPackage
+	if cfg.registerAll {
+		gatewayPackages = collectGatewayPackages(gen, gatewayTargets)
+	}
 
 	if len(gatewayTargets) > 0 {
 		files, err := generator.Generate(gatewayTargets)
@@ -57,16 +91,20 @@ func (generator) Generate(gen *protogen.Plugin) error {
 			return err
 		}
 		for _, f := range files {
-			genFile := gen.NewGeneratedFile(f.GetName(), protogen.GoImportPath(f.GoPkg.Path))
-			if _, err := genFile.Write([]byte(f.GetContent())); err != nil {
+			content := f.GetContent()
+			if cfg.standalone {
+				content = setPackageName(content, f.GoPkg.Name, cfg.packageName(protogen.GoPackageName(f.GoPkg.Name)))
+			}
+			genFile := gen.NewGeneratedFile(cfg.outputLocation(f.GetName(), protogen.GoImportPath(f.GoPkg.Path)))
+			if _, err := genFile.Write([]byte(content)); err != nil {
 				return err
 			}
 		}
+		generateRegisterAll(gen, gatewayPackages, cfg)
 	}
 
 	if len(openapiTargets) > 0 {
-		g := genopenapi.New(reg, genopenapi.FormatJSON)
-		out, err := g.Generate(openapiTargets)
+		out, err := generateOpenAPI(reg, cfg.openAPIFormat, openapiTargets)
 		if err != nil {
 			return err
 		}
@@ -76,6 +114,75 @@ func (generator) Generate(gen *protogen.Plugin) error {
 				return err
 			}
 		}
+		if cfg.embedOpenAPI {
+			generateEmbeddedSpecs(gen, openapiTargets, out, cfg)
+		}
 	}
 	return nil
 }
+
+// outputLocation returns the file name and import path of a file generated
+// alongside the gateway, which is placed in a subpackage in standalone mode.
+func (c *config) outputLocation(name string, importPath protogen.GoImportPath) (string, protogen.GoImportPath) {
+	if !c.standalone {
+		return name, importPath
+	}
+	return path.Join(path.Dir(name), c.standalonePackage, path.Base(name)),
+		protogen.GoImportPath(path.Join(string(importPath), c.standalonePackage))
+}
+
+// packageName returns the name of the package that files generated alongside
+// the gateway are placed in. In standalone mode, this is the name of the
+// subpackage rather than the name of the service package.
+func (c *config) packageName(name protogen.GoPackageName) protogen.GoPackageName {
+	if !c.standalone {
+		return name
+	}
+	return goPackageName(path.Base(c.standalonePackage))
+}
+
+// goPackageName converts a directory name into a valid package name, in the
+// same way as protogen does for import paths.
+func goPackageName(name string) protogen.GoPackageName {
+	name = strings.Map(func(r rune) rune {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) {
+			return r
+		}
+		return '_'
+	}, name)
+	if r, _ := utf8.DecodeRuneInString(name); !unicode.IsLetter(r) || token.Lookup(name).IsKeyword() {
+		name = "_" + name
+	}
+	return protogen.GoPackageName(name)
+}
+
+// setPackageName replaces the package clause and package comment of a file
+// generated by gengateway, which always uses the name of the service package.
+func setPackageName(content, from string, to protogen.GoPackageName) string {
+	content = strings.Replace(content, "\nPackage "+from+" is a reverse proxy.", "\nPackage "+string(to)+" is a reverse proxy.", 1)
+	return strings.Replace(content, "\npackage "+from+"\n", "\npackage "+string(to)+"\n", 1)
+}
+
+// generateOpenAPI runs the OpenAPI generator, which panics on some inputs
+// (such as recursive query parameters) instead of returning an error.
+func generateOpenAPI(reg *descriptor.Registry, format genopenapi.Format, targets []*descriptor.File) (_ []*descriptor.ResponseFile, err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("openapiv2: %v", r)
+		}
+	}()
+	return genopenapi.New(reg, format).Generate(targets)
+}
+
+// hasBindings reports whether any method in the file has an HTTP binding,
+// either from a google.api.http annotation or the gRPC API configuration.
+func hasBindings(f *descriptor.File) bool {
+	for _, svc := range f.Services {
+		for _, m := range svc.Methods {
+			if len(m.Bindings) > 0 {
+				return true
+			}
+		}
+	}
+	return false
+}
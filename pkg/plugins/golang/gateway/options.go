@@ -0,0 +1,239 @@
+package gateway
+
+import (
+	"fmt"
+	"strconv"
+
+	"github.com/kralicky/grpc-gateway/v2/pkg/descriptor"
+	"github.com/kralicky/grpc-gateway/v2/protoc-gen-openapiv2/pkg/genopenapi"
+	"github.com/kralicky/ragu/pkg/util"
+	"github.com/samber/lo"
+)
+
+type Options struct {
+	// Opt is a protoc-style parameter string. In addition to the standard
+	// protogen parameters, the options accepted by protoc-gen-grpc-gateway
+	// are supported, using the same names (e.g. "register_func_suffix=Gw" or
+	// "allow_delete_body=true"). Parameters in Opt take precedence over the
+	// fields below.
+	Opt string
+	// Standalone generates the gateway in a separate package, which imports
+	// the target service package.
+	Standalone bool
+	// StandalonePackage is the name of the package directory the gateway is
+	// generated into when Standalone is set, relative to the service package.
+	// Defaults to "gateway".
+	StandalonePackage string
+	// RegisterFuncSuffix is used to construct the names of the generated
+	// Register*<Suffix> functions. Defaults to "Handler".
+	RegisterFuncSuffix string
+	// UseRequestContext determines whether to use the context of the
+	// http.Request. Defaults to true.
+	UseRequestContext *bool
+	// AllowDeleteBody allows HTTP DELETE methods to have a body.
+	AllowDeleteBody bool
+	// AllowPatchFeature enables the PATCH feature involving update masks
+	// (using google.protobuf.FieldMask).
+	AllowPatchFeature bool
+	// GenerateUnboundMethods generates proxy methods even for RPC methods that
+	// have no HttpRule annotation.
+	GenerateUnboundMethods bool
+	// WarnOnUnboundMethods logs a warning if an RPC method has no HttpRule
+	// annotation. It has no effect if GenerateUnboundMethods is set.
+	WarnOnUnboundMethods bool
+	// OmitPackageDoc omits the package comment from the generated code.
+	OmitPackageDoc bool
+	// RepeatedPathParamSeparator configures how repeated fields in path
+	// parameters are split. One of "csv", "pipes", "ssv", or "tsv". Defaults
+	// to "csv".
+	RepeatedPathParamSeparator string
+	// GrpcAPIConfiguration is the path to a gRPC API Configuration file in
+	// YAML format, containing HTTP rules for methods that are not annotated
+	// in the proto sources.
+	GrpcAPIConfiguration string
+	// RegisterAll generates a register_all.pb.gw.go file in each package,
+	// containing a RegisterAll<Suffix>s function which registers the handlers
+	// for all services in the package, and a GatewayRoutes table listing the
+	// HTTP method, path pattern, and gRPC method of every binding. Only
+	// services generated in the same call to ragu.GenerateCode are included.
+	RegisterAll bool
+
+	// GenerateOpenAPI generates OpenAPI v2 (swagger) definitions for every
+	// file containing methods with HTTP bindings. By default, definitions are
+	// only generated for files with the openapiv2_swagger file option set.
+	GenerateOpenAPI bool
+	// OpenAPIFormat is the output format of OpenAPI definitions, either "json"
+	// or "yaml". Defaults to "json".
+	OpenAPIFormat string
+	// AllowMerge merges the OpenAPI definitions for all files into a single
+	// file named by MergeFileName.
+	AllowMerge bool
+	// MergeFileName is the name prefix of the merged OpenAPI file when
+	// AllowMerge is set. Defaults to "apidocs".
+	MergeFileName string
+	// OpenAPINamingStrategy determines how message names are converted into
+	// OpenAPI definition names. One of "legacy", "fqn", or "simple". Defaults
+	// to "legacy".
+	OpenAPINamingStrategy string
+	// UseJSONNamesForFields uses the JSON names of fields in OpenAPI
+	// definitions, instead of the original proto field names. Unlike
+	// protoc-gen-openapiv2, this is disabled by default.
+	UseJSONNamesForFields bool
+	// EmbedOpenAPI generates a Go file alongside the gateway for each OpenAPI
+	// definition, which contains the definition as a []byte and an
+	// http.Handler serving it. It cannot be used with AllowMerge, and
+	// requires GenerateOpenAPI unless a file has the openapiv2_swagger option.
+	EmbedOpenAPI bool
+	// SwaggerUI additionally serves a Swagger UI page from the handlers
+	// generated by EmbedOpenAPI. The generated code then depends on the
+	// github.com/kralicky/ragu/pkg/swaggerui package, which embeds the
+	// Swagger UI assets.
+	SwaggerUI bool
+}
+
+// config holds the settings for a single call to Generate.
+type config struct {
+	standalone                 bool
+	standalonePackage          string
+	registerFuncSuffix         string
+	useRequestContext          bool
+	allowDeleteBody            bool
+	allowPatchFeature          bool
+	generateUnboundMethods     bool
+	warnOnUnboundMethods       bool
+	omitPackageDoc             bool
+	repeatedPathParamSeparator string
+	grpcAPIConfiguration       string
+	registerAll                bool
+	generateOpenAPI            bool
+	openAPIFormat              genopenapi.Format
+	allowMerge                 bool
+	mergeFileName              string
+	openAPINamingStrategy      string
+	useJSONNamesForFields      bool
+	embedOpenAPI               bool
+	swaggerUI                  bool
+}
+
+func (g generator) config(param string) (config, error) {
+	cfg := config{
+		standalone:                 g.Standalone,
+		standalonePackage:          lo.Ternary(g.StandalonePackage != "", g.StandalonePackage, "gateway"),
+		registerFuncSuffix:         lo.Ternary(g.RegisterFuncSuffix != "", g.RegisterFuncSuffix, "Handler"),
+		useRequestContext:          g.UseRequestContext == nil || *g.UseRequestContext,
+		allowDeleteBody:            g.AllowDeleteBody,
+		allowPatchFeature:          g.AllowPatchFeature,
+		generateUnboundMethods:     g.GenerateUnboundMethods,
+		warnOnUnboundMethods:       g.WarnOnUnboundMethods,
+		omitPackageDoc:             g.OmitPackageDoc,
+		repeatedPathParamSeparator: lo.Ternary(g.RepeatedPathParamSeparator != "", g.RepeatedPathParamSeparator, "csv"),
+		grpcAPIConfiguration:       g.GrpcAPIConfiguration,
+		registerAll:                g.RegisterAll,
+		generateOpenAPI:            g.GenerateOpenAPI,
+		openAPIFormat:              genopenapi.Format(lo.Ternary(g.OpenAPIFormat != "", g.OpenAPIFormat, "json")),
+		allowMerge:                 g.AllowMerge,
+		mergeFileName:              lo.Ternary(g.MergeFileName != "", g.MergeFileName, "apidocs"),
+		openAPINamingStrategy:      lo.Ternary(g.OpenAPINamingStrategy != "", g.OpenAPINamingStrategy, "legacy"),
+		useJSONNamesForFields:      g.UseJSONNamesForFields,
+		embedOpenAPI:               g.EmbedOpenAPI,
+		swaggerUI:                  g.SwaggerUI,
+	}
+	if err := util.ParsePluginParams(param, cfg.set); err != nil {
+		return config{}, err
+	}
+	if err := cfg.openAPIFormat.Validate(); err != nil {
+		return config{}, fmt.Errorf("go-grpc-gateway: %w", err)
+	}
+	if cfg.swaggerUI && !cfg.embedOpenAPI {
+		return config{}, fmt.Errorf("go-grpc-gateway: swagger_ui requires embed_openapi")
+	}
+	if cfg.embedOpenAPI && cfg.allowMerge {
+		return config{}, fmt.Errorf("go-grpc-gateway: embed_openapi cannot be used with allow_merge")
+	}
+	if genopenapi.LookupNamingStrategy(cfg.openAPINamingStrategy) == nil {
+		return config{}, fmt.Errorf("go-grpc-gateway: invalid naming strategy %q", cfg.openAPINamingStrategy)
+	}
+	return cfg, nil
+}
+
+func (c *config) set(name, value string) error {
+	var err error
+	switch name {
+	case "standalone":
+		c.standalone, err = parseBool(value)
+	case "standalone_package":
+		c.standalonePackage = value
+	case "register_func_suffix":
+		c.registerFuncSuffix = value
+	case "request_context":
+		c.useRequestContext, err = parseBool(value)
+	case "allow_delete_body":
+		c.allowDeleteBody, err = parseBool(value)
+	case "allow_patch_feature":
+		c.allowPatchFeature, err = parseBool(value)
+	case "generate_unbound_methods":
+		c.generateUnboundMethods, err = parseBool(value)
+	case "warn_on_unbound_methods":
+		c.warnOnUnboundMethods, err = parseBool(value)
+	case "omit_package_doc":
+		c.omitPackageDoc, err = parseBool(value)
+	case "repeated_path_param_separator":
+		c.repeatedPathParamSeparator = value
+	case "grpc_api_configuration":
+		c.grpcAPIConfiguration = value
+	case "register_all":
+		c.registerAll, err = parseBool(value)
+	case "generate_openapi":
+		c.generateOpenAPI, err = parseBool(value)
+	case "output_format":
+		c.openAPIFormat = genopenapi.Format(value)
+	case "allow_merge":
+		c.allowMerge, err = parseBool(value)
+	case "merge_file_name":
+		c.mergeFileName = value
+	case "openapi_naming_strategy":
+		c.openAPINamingStrategy = value
+	case "json_names_for_fields":
+		c.useJSONNamesForFields, err = parseBool(value)
+	case "embed_openapi":
+		c.embedOpenAPI, err = parseBool(value)
+	case "swagger_ui":
+		c.swaggerUI, err = parseBool(value)
+	default:
+		return fmt.Errorf("go-grpc-gateway: unknown parameter %q", name)
+	}
+	if err != nil {
+		return fmt.Errorf("go-grpc-gateway: bad value for parameter %q: %w", name, err)
+	}
+	return nil
+}
+
+// apply configures the registry. It must be called before the registry
+// is loaded.
+func (c *config) apply(reg *descriptor.Registry) error {
+	if c.grpcAPIConfiguration != "" {
+		if err := reg.LoadGrpcAPIServiceFromYAML(c.grpcAPIConfiguration); err != nil {
+			return err
+		}
+	}
+	reg.SetStandalone(c.standalone)
+	reg.SetAllowDeleteBody(c.allowDeleteBody)
+	reg.SetAllowPatchFeature(c.allowPatchFeature)
+	reg.SetOmitPackageDoc(c.omitPackageDoc)
+	reg.SetWarnOnUnboundMethods(c.warnOnUnboundMethods)
+	reg.SetGenerateUnboundMethods(c.generateUnboundMethods)
+	reg.SetAllowMerge(c.allowMerge)
+	reg.SetMergeFileName(c.mergeFileName)
+	reg.SetOpenAPINamingStrategy(c.openAPINamingStrategy)
+	reg.SetUseJSONNamesForFields(c.useJSONNamesForFields)
+	return reg.SetRepeatedPathParamSeparator(c.repeatedPathParamSeparator)
+}
+
+// parseBool parses a boolean parameter value. As with protoc flags, an empty
+// value means true.
+func parseBool(value string) (bool, error) {
+	if value == "" {
+		return true, nil
+	}
+	return strconv.ParseBool(value)
+}
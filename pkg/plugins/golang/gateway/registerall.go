@@ -0,0 +1,115 @@
+package gateway
+
+import (
+	"path"
+	"strconv"
+	"strings"
+
+	"github.com/kralicky/grpc-gateway/v2/pkg/descriptor"
+	"google.golang.org/protobuf/compiler/protogen"
+)
+
+const (
+	contextPackage = protogen.GoImportPath("context")
+	grpcPackage    = protogen.GoImportPath("google.golang.org/grpc")
+)
+
+// gatewayPackage collects the services with HTTP bindings in a Go package.
+type gatewayPackage struct {
+	file     *protogen.File // first file in the package, used for the output location
+	services []*protogen.Service
+	routes   []route
+}
+
+type route struct {
+	method, pattern, rpc string
+}
+
+// collectGatewayPackages groups the services with HTTP bindings in the
+// target files by Go package. It must be called before the gateway code is
+// generated, which modifies the names in the descriptors.
+func collectGatewayPackages(gen *protogen.Plugin, targets []*descriptor.File) []*gatewayPackage {
+	var packages []*gatewayPackage
+	byImportPath := map[protogen.GoImportPath]*gatewayPackage{}
+	for _, target := range targets {
+		file := gen.FilesByPath[target.GetName()]
+		for i, svc := range target.Services {
+			var routes []route
+			for _, m := range svc.Methods {
+				for _, b := range m.Bindings {
+					routes = append(routes, route{
+						method:  b.HTTPMethod,
+						pattern: b.PathTmpl.Template,
+						rpc:     "/" + strings.TrimPrefix(svc.FQSN(), ".") + "/" + m.GetName(),
+					})
+				}
+			}
+			if len(routes) == 0 {
+				continue
+			}
+			pkg, ok := byImportPath[file.GoImportPath]
+			if !ok {
+				pkg = &gatewayPackage{file: file}
+				byImportPath[file.GoImportPath] = pkg
+				packages = append(packages, pkg)
+			}
+			pkg.services = append(pkg.services, file.Services[i])
+			pkg.routes = append(pkg.routes, routes...)
+		}
+	}
+	return packages
+}
+
+// generateRegisterAll generates a register_all.pb.gw.go file for each Go
+// package, containing a function which registers the handlers for all
+// services in the package, and a table of their routes.
+func generateRegisterAll(gen *protogen.Plugin, packages []*gatewayPackage, cfg config) {
+	for _, pkg := range packages {
+		name := path.Join(path.Dir(pkg.file.GeneratedFilenamePrefix), "register_all.pb.gw.go")
+		g := gen.NewGeneratedFile(cfg.outputLocation(name, pkg.file.GoImportPath))
+		genRegisterAll(g, pkg, cfg)
+	}
+}
+
+func genRegisterAll(g *protogen.GeneratedFile, pkg *gatewayPackage, cfg config) {
+	registerFunc := "RegisterAll" + cfg.registerFuncSuffix + "s"
+	registerFuncType := "func(" + g.QualifiedGoIdent(contextPackage.Ident("Context")) +
+		", *" + g.QualifiedGoIdent(runtimePackage.Ident("ServeMux")) +
+		", *" + g.QualifiedGoIdent(grpcPackage.Ident("ClientConn")) + ") error"
+
+	g.P("// Code generated by protoc-gen-grpc-gateway. DO NOT EDIT.")
+	g.P()
+	g.P("package ", cfg.packageName(pkg.file.GoPackageName))
+	g.P()
+	g.P("// GatewayRoute describes an HTTP route served by the gateway.")
+	g.P("type GatewayRoute struct {")
+	g.P("// HTTP method, e.g. \"GET\".")
+	g.P("Method string `json:\"method\"`")
+	g.P("// Path template, e.g. \"/v1/{name=shelves/*}\".")
+	g.P("Pattern string `json:\"pattern\"`")
+	g.P("// Full name of the gRPC method, e.g. \"/pkg.Service/Method\".")
+	g.P("RPC string `json:\"rpc\"`")
+	g.P("}")
+	g.P()
+	g.P("// GatewayRoutes lists the HTTP routes of all services in this package.")
+	g.P("var GatewayRoutes = []GatewayRoute{")
+	for _, r := range pkg.routes {
+		g.P("{Method: ", strconv.Quote(r.method), ", Pattern: ", strconv.Quote(r.pattern), ", RPC: ", strconv.Quote(r.rpc), "},")
+	}
+	g.P("}")
+	g.P()
+	g.P("// ", registerFunc, " registers the http handlers for all services in this")
+	g.P("// package to \"mux\". The handlers forward requests to the grpc endpoint over \"conn\".")
+	g.P("func ", registerFunc, "(ctx ", contextPackage.Ident("Context"), ", mux *", runtimePackage.Ident("ServeMux"), ", conn *", grpcPackage.Ident("ClientConn"), ") error {")
+	g.P("for _, register := range []", registerFuncType, "{")
+	for _, svc := range pkg.services {
+		g.P("Register", svc.GoName, cfg.registerFuncSuffix, ",")
+	}
+	g.P("} {")
+	g.P("if err := register(ctx, mux, conn); err != nil {")
+	g.P("return err")
+	g.P("}")
+	g.P("}")
+	g.P("return nil")
+	g.P("}")
+}
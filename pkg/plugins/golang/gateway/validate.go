@@ -0,0 +1,249 @@
+package gateway
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/kralicky/grpc-gateway/v2/pkg/descriptor"
+	"google.golang.org/genproto/googleapis/api/annotations"
+	"google.golang.org/protobuf/compiler/protogen"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protoreflect"
+)
+
+// Field numbers used to locate HTTP rules in the source.
+const (
+	methodOptionsField      = 4
+	httpRuleGetField        = 2
+	httpRulePutField        = 3
+	httpRulePostField       = 4
+	httpRuleDeleteField     = 5
+	httpRulePatchField      = 6
+	httpRuleBodyField       = 7
+	httpRuleCustomField     = 8
+	httpRuleAdditionalField = 11
+	httpRuleResponseField   = 12
+)
+
+// binding is a single HTTP rule of a method.
+type binding struct {
+	method *protogen.Method
+	rule   *annotations.HttpRule
+	// path is the source path of the rule, or nil if the rule was loaded from
+	// the gRPC API configuration.
+	path protoreflect.SourcePath
+}
+
+// position returns the source position of the given field of the rule, or
+// of the closest enclosing element that has a known position.
+func (b binding) position(field ...int32) string {
+	file := b.method.Desc.ParentFile()
+	path := b.method.Location.Path
+	if b.path != nil {
+		path = append(append(protoreflect.SourcePath{}, b.path...), field...)
+	}
+	for n := len(path); n > 0; n-- {
+		if loc := file.SourceLocations().ByPath(path[:n]); len(loc.Path) > 0 {
+			return fmt.Sprintf("%s:%d:%d", file.Path(), loc.StartLine+1, loc.StartColumn+1)
+		}
+	}
+	return file.Path()
+}
+
+type bindingValidator struct {
+	allowDeleteBody bool
+	errs            []error
+	// routes maps normalized routes to the first binding that declared them
+	routes map[string]binding
+}
+
+// validateBindings checks the HTTP rules of every method in the files to
+// generate against the method's input and output messages, and checks for
+// conflicting routes across all files. Rules from the gRPC API configuration
+// are checked along with the google.api.http annotations; since they have no
+// position in the proto source, errors for them are reported at the method.
+func validateBindings(gen *protogen.Plugin, reg *descriptor.Registry, allowDeleteBody bool) error {
+	v := &bindingValidator{
+		allowDeleteBody: allowDeleteBody,
+		routes:          map[string]binding{},
+	}
+	for _, f := range gen.Files {
+		if !f.Generate {
+			continue
+		}
+		for _, svc := range f.Services {
+			for _, method := range svc.Methods {
+				for _, rule := range reg.LookupExternalHTTPRules("." + string(method.Desc.FullName())) {
+					v.validate(binding{method: method, rule: rule}, false)
+				}
+				rule, ok := proto.GetExtension(method.Desc.Options(), annotations.E_Http).(*annotations.HttpRule)
+				if !ok || rule == nil {
+					continue
+				}
+				path := append(append(protoreflect.SourcePath{}, method.Location.Path...),
+					methodOptionsField, int32(annotations.E_Http.TypeDescriptor().Number()))
+				v.validate(binding{method: method, rule: rule, path: path}, false)
+			}
+		}
+	}
+	return errors.Join(v.errs...)
+}
+
+func (v *bindingValidator) errorf(b binding, field int32, format string, args ...any) {
+	v.errs = append(v.errs, fmt.Errorf("%s: %s: %s", b.position(field), b.method.Desc.FullName(), fmt.Sprintf(format, args...)))
+}
+
+func (v *bindingValidator) validate(b binding, additional bool) {
+	rule := b.rule
+	var httpMethod, template string
+	var patternField int32
+	switch pattern := rule.GetPattern().(type) {
+	case *annotations.HttpRule_Get:
+		httpMethod, template, patternField = "GET", pattern.Get, httpRuleGetField
+	case *annotations.HttpRule_Put:
+		httpMethod, template, patternField = "PUT", pattern.Put, httpRulePutField
+	case *annotations.HttpRule_Post:
+		httpMethod, template, patternField = "POST", pattern.Post, httpRulePostField
+	case *annotations.HttpRule_Delete:
+		httpMethod, template, patternField = "DELETE", pattern.Delete, httpRuleDeleteField
+	case *annotations.HttpRule_Patch:
+		httpMethod, template, patternField = "PATCH", pattern.Patch, httpRulePatchField
+	case *annotations.HttpRule_Custom:
+		httpMethod, template, patternField = pattern.Custom.GetKind(), pattern.Custom.GetPath(), httpRuleCustomField
+		if httpMethod == "" {
+			v.errorf(b, patternField, "custom pattern is missing a kind")
+			return
+		}
+	default:
+		v.errorf(b, 0, "HTTP rule has no method pattern (one of get, put, post, delete, patch, or custom)")
+		return
+	}
+
+	route, variables, err := parseTemplate(template)
+	if err != nil {
+		v.errorf(b, patternField, "invalid path template %q: %v", template, err)
+	} else {
+		for _, variable := range variables {
+			if err := checkPathField(b.method.Input, variable); err != nil {
+				v.errorf(b, patternField, "path variable %q: %v", variable, err)
+			}
+		}
+		key := httpMethod + " " + route
+		if prev, ok := v.routes[key]; ok {
+			v.errorf(b, patternField, "route %s %s conflicts with %s (%s)", httpMethod, template, prev.method.Desc.FullName(), prev.position(patternField))
+		} else {
+			v.routes[key] = b
+		}
+	}
+
+	switch body := rule.GetBody(); body {
+	case "", "*":
+	default:
+		if b.method.Input.Desc.Fields().ByName(protoreflect.Name(body)) == nil {
+			v.errorf(b, httpRuleBodyField, "body field %q does not exist in %s", body, b.method.Input.Desc.FullName())
+		}
+	}
+	if rule.GetBody() != "" {
+		switch {
+		case httpMethod == "GET":
+			v.errorf(b, httpRuleBodyField, "GET methods cannot have a body")
+		case httpMethod == "DELETE" && !v.allowDeleteBody:
+			v.errorf(b, httpRuleBodyField, "DELETE methods cannot have a body unless allow_delete_body is set")
+		}
+	}
+
+	if responseBody := rule.GetResponseBody(); responseBody != "" {
+		if b.method.Output.Desc.Fields().ByName(protoreflect.Name(responseBody)) == nil {
+			v.errorf(b, httpRuleResponseField, "response_body field %q does not exist in %s", responseBody, b.method.Output.Desc.FullName())
+		}
+	}
+
+	for i, additionalRule := range rule.GetAdditionalBindings() {
+		if additional {
+			v.errorf(b, httpRuleAdditionalField, "additional bindings cannot be nested")
+			break
+		}
+		next := binding{method: b.method, rule: additionalRule}
+		if b.path != nil {
+			next.path = append(append(protoreflect.SourcePath{}, b.path...), httpRuleAdditionalField, int32(i))
+		}
+		v.validate(next, true)
+	}
+}
+
+// parseTemplate parses a path template, returning the route with variables
+// replaced by their segment patterns (used to detect conflicting routes), and
+// the field paths of the variables.
+func parseTemplate(template string) (route string, variables []string, _ error) {
+	if !strings.HasPrefix(template, "/") {
+		return "", nil, errors.New("must start with '/'")
+	}
+	var sb strings.Builder
+	seen := map[string]bool{}
+	for rest := template; rest != ""; {
+		open := strings.IndexAny(rest, "{}")
+		if open == -1 {
+			sb.WriteString(rest)
+			break
+		}
+		if rest[open] == '}' {
+			return "", nil, errors.New("unbalanced '}'")
+		}
+		sb.WriteString(rest[:open])
+		rest = rest[open+1:]
+		end := strings.IndexAny(rest, "{}")
+		if end == -1 || rest[end] == '{' {
+			return "", nil, errors.New("unterminated or nested variable")
+		}
+		fieldPath, pattern, hasPattern := strings.Cut(rest[:end], "=")
+		if !hasPattern {
+			pattern = "*"
+		} else if pattern == "" {
+			return "", nil, fmt.Errorf("variable %q has an empty pattern", fieldPath)
+		}
+		if fieldPath == "" {
+			return "", nil, errors.New("empty variable name")
+		}
+		if seen[fieldPath] {
+			return "", nil, fmt.Errorf("variable %q is used more than once", fieldPath)
+		}
+		seen[fieldPath] = true
+		variables = append(variables, fieldPath)
+		sb.WriteString(pattern)
+		rest = rest[end+1:]
+	}
+	return sb.String(), variables, nil
+}
+
+// checkPathField checks that a dot-separated field path refers to a field
+// which can be populated from a path variable.
+func checkPathField(msg *protogen.Message, fieldPath string) error {
+	names := strings.Split(fieldPath, ".")
+	for i, name := range names {
+		field := msg.Desc.Fields().ByName(protoreflect.Name(name))
+		if field == nil {
+			return fmt.Errorf("field %q does not exist in %s", name, msg.Desc.FullName())
+		}
+		var fieldMsg *protogen.Message
+		for _, f := range msg.Fields {
+			if f.Desc == field {
+				fieldMsg = f.Message
+			}
+		}
+		if i == len(names)-1 {
+			if field.IsMap() {
+				return fmt.Errorf("map field %q cannot be used as a path variable", name)
+			}
+			if fieldMsg != nil && fieldMsg.Desc.ParentFile().Package() != "google.protobuf" {
+				return fmt.Errorf("message field %q cannot be used as a path variable", name)
+			}
+			return nil
+		}
+		if fieldMsg == nil || field.IsList() || field.IsMap() {
+			return fmt.Errorf("field %q is not a singular message field", name)
+		}
+		msg = fieldMsg
+	}
+	return nil
+}
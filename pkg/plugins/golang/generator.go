@@ -1,19 +1,44 @@
 package golang
 
 import (
+	"fmt"
+
+	"github.com/kralicky/ragu/pkg/util"
 	gengo "google.golang.org/protobuf/cmd/protoc-gen-go/internal_gengo"
 	"google.golang.org/protobuf/compiler/protogen"
 )
 
 var Generator = generator{}
 
-type generator struct{}
+type Options struct {
+	// Opt is a protoc-style parameter string, e.g. "paths=source_relative".
+	// Only the standard protogen parameters (paths, module, annotate_code,
+	// and M<file>=<importpath>) are accepted.
+	Opt string
+}
+
+func NewGenerator(opts Options) generator {
+	return generator{Options: opts}
+}
+
+type generator struct {
+	Options
+}
 
 func (generator) Name() string {
 	return "go"
 }
 
+func (g generator) Parameter() string {
+	return g.Opt
+}
+
 func (generator) Generate(gen *protogen.Plugin) error {
+	if err := util.ParsePluginParams(gen.Request.GetParameter(), func(name, _ string) error {
+		return fmt.Errorf("go: unknown parameter %q", name)
+	}); err != nil {
+		return err
+	}
 	for _, f := range gen.Files {
 		if f.Generate {
 			gengo.GenerateFile(gen, f)
@@ -0,0 +1,30 @@
+package golang_test
+
+import (
+	"testing"
+
+	"github.com/kralicky/ragu"
+	"github.com/kralicky/ragu/internal/gentest"
+	"github.com/kralicky/ragu/pkg/plugins/golang"
+)
+
+func TestParameters(t *testing.T) {
+	out, err := ragu.GenerateCode([]ragu.Generator{
+		golang.NewGenerator(golang.Options{Opt: "annotate_code"}),
+	}, "../../../testdata/pkg1/*.proto", "../../../testdata/pkg2/*.proto")
+	if err != nil {
+		t.Fatal(err)
+	}
+	m := gentest.NewModule(t)
+	m.WriteGenerated(out)
+	m.Build()
+}
+
+func TestUnknownParameter(t *testing.T) {
+	_, err := ragu.GenerateCode([]ragu.Generator{
+		golang.NewGenerator(golang.Options{Opt: "require_unimplemented_servers=false"}),
+	}, "../../../testdata/pkg1/*.proto")
+	if err == nil || err.Error() != `go: unknown parameter "require_unimplemented_servers"` {
+		t.Fatalf("expected an error for an unknown parameter, got %v", err)
+	}
+}
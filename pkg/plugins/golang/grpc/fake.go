@@ -0,0 +1,362 @@
+package grpc
+
+import (
+	"path"
+	"strings"
+
+	"google.golang.org/protobuf/compiler/protogen"
+)
+
+const (
+	ioPackage       = protogen.GoImportPath("io")
+	syncPackage     = protogen.GoImportPath("sync")
+	metadataPackage = protogen.GoImportPath("google.golang.org/grpc/metadata")
+)
+
+var FakeGenerator = fakeGenerator{}
+
+// NewFakeGenerator returns a go-grpc-fake generator with the given options.
+// The options must match those of the go-grpc generator, so that the fakes
+// implement the generated interfaces.
+func NewFakeGenerator(opts Options) fakeGenerator {
+	return fakeGenerator{generator{Options: opts}}
+}
+
+// fakeGenerator generates programmable fakes of the client and server
+// interfaces and streams generated by the go-grpc generator. The fakes have
+// no dependencies other than grpc, and are placed in a "<package>fake"
+// subpackage.
+type fakeGenerator struct {
+	generator
+}
+
+func (fakeGenerator) Name() string {
+	return "go-grpc-fake"
+}
+
+func (g fakeGenerator) Generate(gen *protogen.Plugin) error {
+	cfg, err := g.config(gen.Request.GetParameter())
+	if err != nil {
+		return err
+	}
+	for _, f := range gen.Files {
+		if f.Generate {
+			generateFakeFile(gen, f, cfg)
+		}
+	}
+	return nil
+}
+
+// generateFakeFile generates a _grpc_fake.pb.go file containing fakes for the
+// services in the file.
+func generateFakeFile(gen *protogen.Plugin, file *protogen.File, cfg config) *protogen.GeneratedFile {
+	if len(file.Services) == 0 {
+		return nil
+	}
+	pkgName := string(file.GoPackageName) + "fake"
+	filename := path.Join(path.Dir(file.GeneratedFilenamePrefix), pkgName, path.Base(file.GeneratedFilenamePrefix)+"_grpc_fake.pb.go")
+	g := gen.NewGeneratedFile(filename, protogen.GoImportPath(path.Join(string(file.GoImportPath), pkgName)))
+	g.P("// Code generated by protoc-gen-go-grpc-fake. DO NOT EDIT.")
+	g.P("// source: ", file.Desc.Path())
+	g.P()
+	g.P("package ", pkgName)
+	g.P()
+	for _, service := range file.Services {
+		genFakeClient(g, file, service, cfg)
+		genFakeServer(g, file, service, cfg)
+		for _, method := range service.Methods {
+			if method.Desc.IsStreamingClient() || method.Desc.IsStreamingServer() {
+				genFakeClientStream(g, file, method, cfg)
+				genFakeServerStream(g, file, method, cfg)
+			}
+		}
+	}
+	return g
+}
+
+func genFakeClient(g *protogen.GeneratedFile, file *protogen.File, service *protogen.Service, cfg config) {
+	clientName := cfg.clientName(service)
+	clientIdent := g.QualifiedGoIdent(file.GoImportPath.Ident(clientName))
+
+	g.P("// ", clientName, " is a programmable fake implementation of ", clientIdent, ".")
+	g.P("// Each method calls the corresponding function field, or returns a")
+	g.P("// codes.Unimplemented error if it is nil.")
+	g.P("type ", clientName, " struct {")
+	for _, method := range service.Methods {
+		sig := clientSignature(g, file, method, cfg)
+		g.P(method.GoName, "Func func", strings.TrimPrefix(sig, method.GoName))
+	}
+	g.P("}")
+	g.P()
+	g.P("var _ ", clientIdent, " = (*", clientName, ")(nil)")
+	g.P()
+	for _, method := range service.Methods {
+		args := "ctx, "
+		if !method.Desc.IsStreamingClient() {
+			args += "in, "
+		}
+		args += "opts..."
+		g.P("func (f *", clientName, ") ", clientSignature(g, file, method, cfg), " {")
+		g.P("if f.", method.GoName, "Func == nil {")
+		g.P("return nil, ", statusPackage.Ident("Errorf"), "(", codesPackage.Ident("Unimplemented"), `, "method `, method.GoName, ` not implemented")`)
+		g.P("}")
+		g.P("return f.", method.GoName, "Func(", args, ")")
+		g.P("}")
+		g.P()
+	}
+}
+
+func genFakeServer(g *protogen.GeneratedFile, file *protogen.File, service *protogen.Service, cfg config) {
+	serverName := cfg.serverName(service)
+	serverIdent := g.QualifiedGoIdent(file.GoImportPath.Ident(serverName))
+	unimplemented := "Unimplemented" + serverName
+
+	g.P("// ", serverName, " is a programmable fake implementation of ", serverIdent, ".")
+	g.P("// Each method calls the corresponding function field, or the method of the")
+	g.P("// embedded ", unimplemented, " if it is nil.")
+	g.P("type ", serverName, " struct {")
+	g.P(file.GoImportPath.Ident(unimplemented))
+	for _, method := range service.Methods {
+		sig := serverSignature(g, file, method, cfg)
+		g.P(method.GoName, "Func func", strings.TrimPrefix(sig, method.GoName))
+	}
+	g.P("}")
+	g.P()
+	g.P("var _ ", serverIdent, " = (*", serverName, ")(nil)")
+	g.P()
+	for _, method := range service.Methods {
+		var params, args []string
+		if !method.Desc.IsStreamingClient() && !method.Desc.IsStreamingServer() {
+			params = append(params, "ctx "+g.QualifiedGoIdent(contextPackage.Ident("Context")))
+			args = append(args, "ctx")
+		}
+		if !method.Desc.IsStreamingClient() {
+			params = append(params, "in *"+g.QualifiedGoIdent(method.Input.GoIdent))
+			args = append(args, "in")
+		}
+		ret := "error"
+		if method.Desc.IsStreamingClient() || method.Desc.IsStreamingServer() {
+			params = append(params, "stream "+serverStreamType(g, file, method, cfg))
+			args = append(args, "stream")
+		} else {
+			ret = "(*" + g.QualifiedGoIdent(method.Output.GoIdent) + ", error)"
+		}
+		g.P("func (f *", serverName, ") ", method.GoName, "(", strings.Join(params, ", "), ") ", ret, " {")
+		g.P("if f.", method.GoName, "Func == nil {")
+		g.P("return f.", unimplemented, ".", method.GoName, "(", strings.Join(args, ", "), ")")
+		g.P("}")
+		g.P("return f.", method.GoName, "Func(", strings.Join(args, ", "), ")")
+		g.P("}")
+		g.P()
+	}
+}
+
+// genFakeClientStream generates a fake of the client side of a stream, which
+// can be returned from a fake client.
+func genFakeClientStream(g *protogen.GeneratedFile, file *protogen.File, method *protogen.Method, cfg config) {
+	name := method.Parent.GoName + "_" + method.GoName + "Client"
+	send := method.Desc.IsStreamingClient()
+	recv := method.Desc.IsStreamingServer()
+
+	g.P("// ", name, " is a fake client stream for the ", method.GoName, " method.")
+	if send {
+		g.P("// Messages passed to Send are appended to Requests.")
+	}
+	if recv {
+		g.P("// Recv returns the messages in Responses in order, followed by RecvErr, or")
+		g.P("// io.EOF if RecvErr is nil.")
+	} else {
+		g.P("// CloseAndRecv returns Response, or RecvErr if it is set.")
+	}
+	g.P("// SendMsg and RecvMsg are forwarded to the embedded ClientStream, which is")
+	g.P("// nil by default.")
+	g.P("type ", name, " struct {")
+	g.P(grpcPackage.Ident("ClientStream"))
+	g.P()
+	g.P("Ctx ", contextPackage.Ident("Context"))
+	if send {
+		g.P("Requests []*", method.Input.GoIdent)
+	}
+	if recv {
+		g.P("Responses []*", method.Output.GoIdent)
+	} else {
+		g.P("Response *", method.Output.GoIdent)
+	}
+	g.P("RecvErr error")
+	g.P("HeaderMD ", metadataPackage.Ident("MD"))
+	g.P("TrailerMD ", metadataPackage.Ident("MD"))
+	g.P("// Closed is set by CloseSend and CloseAndRecv.")
+	g.P("Closed bool")
+	g.P()
+	g.P("mu ", syncPackage.Ident("Mutex"))
+	g.P("}")
+	g.P()
+	g.P("var _ ", clientStreamType(g, file, method, cfg), " = (*", name, ")(nil)")
+	g.P()
+
+	if send {
+		g.P("func (x *", name, ") Send(m *", method.Input.GoIdent, ") error {")
+		g.P("x.mu.Lock()")
+		g.P("defer x.mu.Unlock()")
+		g.P("x.Requests = append(x.Requests, m)")
+		g.P("return nil")
+		g.P("}")
+		g.P()
+	}
+	if recv {
+		g.P("func (x *", name, ") Recv() (*", method.Output.GoIdent, ", error) {")
+		g.P("x.mu.Lock()")
+		g.P("defer x.mu.Unlock()")
+		g.P("if len(x.Responses) == 0 {")
+		g.P("if x.RecvErr != nil {")
+		g.P("return nil, x.RecvErr")
+		g.P("}")
+		g.P("return nil, ", ioPackage.Ident("EOF"))
+		g.P("}")
+		g.P("m := x.Responses[0]")
+		g.P("x.Responses = x.Responses[1:]")
+		g.P("return m, nil")
+		g.P("}")
+		g.P()
+	} else {
+		g.P("func (x *", name, ") CloseAndRecv() (*", method.Output.GoIdent, ", error) {")
+		g.P("x.mu.Lock()")
+		g.P("defer x.mu.Unlock()")
+		g.P("x.Closed = true")
+		g.P("if x.RecvErr != nil {")
+		g.P("return nil, x.RecvErr")
+		g.P("}")
+		g.P("return x.Response, nil")
+		g.P("}")
+		g.P()
+	}
+	g.P("func (x *", name, ") Header() (", metadataPackage.Ident("MD"), ", error) {")
+	g.P("return x.HeaderMD, nil")
+	g.P("}")
+	g.P()
+	g.P("func (x *", name, ") Trailer() ", metadataPackage.Ident("MD"), " {")
+	g.P("return x.TrailerMD")
+	g.P("}")
+	g.P()
+	g.P("func (x *", name, ") CloseSend() error {")
+	g.P("x.mu.Lock()")
+	g.P("defer x.mu.Unlock()")
+	g.P("x.Closed = true")
+	g.P("return nil")
+	g.P("}")
+	g.P()
+	genFakeStreamContext(g, name)
+}
+
+// genFakeServerStream generates a fake of the server side of a stream, which
+// can be passed to a server implementation.
+func genFakeServerStream(g *protogen.GeneratedFile, file *protogen.File, method *protogen.Method, cfg config) {
+	name := method.Parent.GoName + "_" + method.GoName + "Server"
+	send := method.Desc.IsStreamingServer()
+	recv := method.Desc.IsStreamingClient()
+
+	g.P("// ", name, " is a fake server stream for the ", method.GoName, " method.")
+	if recv {
+		g.P("// Recv returns the messages in Requests in order, followed by RecvErr, or")
+		g.P("// io.EOF if RecvErr is nil.")
+	}
+	if send {
+		g.P("// Messages passed to Send are appended to Sent.")
+	} else {
+		g.P("// The message passed to SendAndClose is stored in Response.")
+	}
+	g.P("// SendErr, if set, is returned instead. Headers and trailers are collected")
+	g.P("// in HeaderMD and TrailerMD. SendMsg and RecvMsg are forwarded to the")
+	g.P("// embedded ServerStream, which is nil by default.")
+	g.P("type ", name, " struct {")
+	g.P(grpcPackage.Ident("ServerStream"))
+	g.P()
+	g.P("Ctx ", contextPackage.Ident("Context"))
+	if recv {
+		g.P("Requests []*", method.Input.GoIdent)
+		g.P("RecvErr error")
+	}
+	if send {
+		g.P("Sent []*", method.Output.GoIdent)
+	} else {
+		g.P("Response *", method.Output.GoIdent)
+	}
+	g.P("SendErr error")
+	g.P("HeaderMD ", metadataPackage.Ident("MD"))
+	g.P("TrailerMD ", metadataPackage.Ident("MD"))
+	g.P()
+	g.P("mu ", syncPackage.Ident("Mutex"))
+	g.P("}")
+	g.P()
+	g.P("var _ ", serverStreamType(g, file, method, cfg), " = (*", name, ")(nil)")
+	g.P()
+
+	if send {
+		g.P("func (x *", name, ") Send(m *", method.Output.GoIdent, ") error {")
+		g.P("x.mu.Lock()")
+		g.P("defer x.mu.Unlock()")
+		g.P("if x.SendErr != nil {")
+		g.P("return x.SendErr")
+		g.P("}")
+		g.P("x.Sent = append(x.Sent, m)")
+		g.P("return nil")
+		g.P("}")
+		g.P()
+	} else {
+		g.P("func (x *", name, ") SendAndClose(m *", method.Output.GoIdent, ") error {")
+		g.P("x.mu.Lock()")
+		g.P("defer x.mu.Unlock()")
+		g.P("if x.SendErr != nil {")
+		g.P("return x.SendErr")
+		g.P("}")
+		g.P("x.Response = m")
+		g.P("return nil")
+		g.P("}")
+		g.P()
+	}
+	if recv {
+		g.P("func (x *", name, ") Recv() (*", method.Input.GoIdent, ", error) {")
+		g.P("x.mu.Lock()")
+		g.P("defer x.mu.Unlock()")
+		g.P("if len(x.Requests) == 0 {")
+		g.P("if x.RecvErr != nil {")
+		g.P("return nil, x.RecvErr")
+		g.P("}")
+		g.P("return nil, ", ioPackage.Ident("EOF"))
+		g.P("}")
+		g.P("m := x.Requests[0]")
+		g.P("x.Requests = x.Requests[1:]")
+		g.P("return m, nil")
+		g.P("}")
+		g.P()
+	}
+	g.P("func (x *", name, ") SetHeader(md ", metadataPackage.Ident("MD"), ") error {")
+	g.P("x.mu.Lock()")
+	g.P("defer x.mu.Unlock()")
+	g.P("x.HeaderMD = ", metadataPackage.Ident("Join"), "(x.HeaderMD, md)")
+	g.P("return nil")
+	g.P("}")
+	g.P()
+	g.P("func (x *", name, ") SendHeader(md ", metadataPackage.Ident("MD"), ") error {")
+	g.P("return x.SetHeader(md)")
+	g.P("}")
+	g.P()
+	g.P("func (x *", name, ") SetTrailer(md ", metadataPackage.Ident("MD"), ") {")
+	g.P("x.mu.Lock()")
+	g.P("defer x.mu.Unlock()")
+	g.P("x.TrailerMD = ", metadataPackage.Ident("Join"), "(x.TrailerMD, md)")
+	g.P("}")
+	g.P()
+	genFakeStreamContext(g, name)
+}
+
+func genFakeStreamContext(g *protogen.GeneratedFile, name string) {
+	g.P("// Context returns Ctx, or context.Background() if it is nil.")
+	g.P("func (x *", name, ") Context() ", contextPackage.Ident("Context"), " {")
+	g.P("if x.Ctx == nil {")
+	g.P("return ", contextPackage.Ident("Background"), "()")
+	g.P("}")
+	g.P("return x.Ctx")
+	g.P("}")
+	g.P()
+}
@@ -1,7 +1,11 @@
 package grpc
 
 import (
-	"github.com/samber/lo"
+	"fmt"
+	"strconv"
+	"sync/atomic"
+
+	"github.com/kralicky/ragu/pkg/util"
 	"google.golang.org/protobuf/compiler/protogen"
 
 	_ "google.golang.org/genproto/googleapis/api/annotations"
@@ -15,25 +19,180 @@ import (
 
 const version = "1.3.0"
 
-var requireUnimplemented *bool = lo.ToPtr(true)
+// Version of protoc-gen-go-grpc that generic stream output is derived from.
+const genericStreamsVersion = "1.5.1"
+
+// Minimum gRPC-Go version required by each supported SupportPackageIsVersion
+// assertion.
+var supportPackageVersions = map[int]string{
+	7: "v1.32.0",
+	8: "v1.62.0",
+	9: "v1.64.0",
+}
+
+// Generator is the go-grpc generator with default options. Unlike generators
+// returned by NewGenerator, it is affected by SetRequireUnimplemented.
+var Generator = generator{legacy: true}
+
+var requireUnimplemented atomic.Bool
 
+func init() {
+	requireUnimplemented.Store(true)
+}
+
+// SetRequireUnimplemented sets whether server implementations generated by
+// the package-level Generator must embed the generated
+// Unimplemented<Service>Server type. The setting is process-wide, and applies
+// to every use of Generator, including the ragu presets; generators returned
+// by NewGenerator are not affected.
+//
+// Deprecated: Use NewGenerator with Options.RequireUnimplemented instead.
 func SetRequireUnimplemented(req bool) {
-	*requireUnimplemented = req
+	requireUnimplemented.Store(req)
 }
 
-var Generator = generator{}
+type Options struct {
+	// Opt is a protoc-style parameter string. In addition to the standard
+	// protogen parameters, "require_unimplemented_servers=<bool>" and
+	// "use_generic_streams_experimental=<bool>" are accepted.
+	// Parameters in Opt take precedence over the fields below.
+	Opt string
+	// RequireUnimplemented controls whether server implementations must embed
+	// the generated Unimplemented<Service>Server type. Defaults to true.
+	RequireUnimplemented *bool
+	// ClientSuffix is appended to the service name to form the name of the
+	// client interface. Defaults to "Client".
+	ClientSuffix string
+	// ServerSuffix is appended to the service name to form the name of the
+	// server interface and its Register<Service><ServerSuffix> function.
+	// Defaults to "Server".
+	ServerSuffix string
+	// SupportPackageVersion selects the grpc.SupportPackageIsVersion<N>
+	// assertion, and with it the minimum gRPC-Go version the generated code
+	// requires. Supported versions are 7, 8, and 9. Defaults to 7, or 9 if
+	// GenericStreams is set. Version 9 implies GenericStreams.
+	SupportPackageVersion int
+	// GenericStreams generates streaming methods using the generic stream
+	// types from gRPC-Go v1.64.0 (e.g. grpc.ServerStreamingClient[T]), matching
+	// the output of newer versions of protoc-gen-go-grpc. The named stream
+	// types from previous versions are kept as type aliases.
+	GenericStreams bool
+}
 
-type generator struct{}
+// NewGenerator returns a go-grpc generator with the given options. The
+// generator holds no mutable state, and can be used concurrently.
+func NewGenerator(opts Options) generator {
+	return generator{Options: opts}
+}
+
+type generator struct {
+	Options
+	// legacy is set for the package-level Generator, whose default for
+	// RequireUnimplemented is set by SetRequireUnimplemented.
+	legacy bool
+}
 
 func (generator) Name() string {
 	return "go-grpc"
 }
 
-func (generator) Generate(gen *protogen.Plugin) error {
+func (g generator) Parameter() string {
+	return g.Opt
+}
+
+func (g generator) Generate(gen *protogen.Plugin) error {
+	cfg, err := g.config(gen.Request.GetParameter())
+	if err != nil {
+		return err
+	}
 	for _, f := range gen.Files {
 		if f.Generate {
-			generateFile(gen, f)
+			generateFile(gen, f, cfg)
 		}
 	}
 	return nil
 }
+
+// config holds the settings for a single call to Generate.
+type config struct {
+	requireUnimplemented  bool
+	clientSuffix          string
+	serverSuffix          string
+	supportPackageVersion int
+	genericStreams        bool
+}
+
+func (g generator) config(param string) (config, error) {
+	cfg := config{
+		requireUnimplemented:  true,
+		clientSuffix:          "Client",
+		serverSuffix:          "Server",
+		supportPackageVersion: 7,
+	}
+	if g.legacy {
+		cfg.requireUnimplemented = requireUnimplemented.Load()
+	}
+	if g.RequireUnimplemented != nil {
+		cfg.requireUnimplemented = *g.RequireUnimplemented
+	}
+	if g.ClientSuffix != "" {
+		cfg.clientSuffix = g.ClientSuffix
+	}
+	if g.ServerSuffix != "" {
+		cfg.serverSuffix = g.ServerSuffix
+	}
+	if g.SupportPackageVersion != 0 {
+		if _, ok := supportPackageVersions[g.SupportPackageVersion]; !ok {
+			return config{}, fmt.Errorf("go-grpc: unsupported SupportPackageVersion %d", g.SupportPackageVersion)
+		}
+		cfg.supportPackageVersion = g.SupportPackageVersion
+	}
+	cfg.genericStreams = g.GenericStreams || cfg.supportPackageVersion >= 9
+	if cfg.clientSuffix == cfg.serverSuffix {
+		return config{}, fmt.Errorf("go-grpc: client and server suffixes must be different")
+	}
+	if err := util.ParsePluginParams(param, cfg.set); err != nil {
+		return config{}, err
+	}
+	if cfg.genericStreams {
+		if g.SupportPackageVersion != 0 && g.SupportPackageVersion < 9 {
+			return config{}, fmt.Errorf("go-grpc: generic streams require SupportPackageVersion 9 (got %d)", g.SupportPackageVersion)
+		}
+		cfg.supportPackageVersion = 9
+	} else if cfg.supportPackageVersion >= 9 {
+		return config{}, fmt.Errorf("go-grpc: SupportPackageVersion %d requires generic streams", cfg.supportPackageVersion)
+	}
+	return cfg, nil
+}
+
+func (c *config) set(name, value string) error {
+	switch name {
+	case "require_unimplemented_servers":
+		v, err := strconv.ParseBool(value)
+		if err != nil {
+			return fmt.Errorf("go-grpc: bad value for parameter %q: %w", name, err)
+		}
+		c.requireUnimplemented = v
+	case "use_generic_streams_experimental":
+		v, err := strconv.ParseBool(value)
+		if err != nil {
+			return fmt.Errorf("go-grpc: bad value for parameter %q: %w", name, err)
+		}
+		c.genericStreams = v
+	default:
+		return fmt.Errorf("go-grpc: unknown parameter %q", name)
+	}
+	return nil
+}
+
+func (c config) clientName(service *protogen.Service) string {
+	return service.GoName + c.clientSuffix
+}
+
+func (c config) serverName(service *protogen.Service) string {
+	return service.GoName + c.serverSuffix
+}
+
+func (c config) registerName(service *protogen.Service) string {
+	return "Register" + c.serverName(service)
+}
@@ -0,0 +1,51 @@
+package grpc
+
+import (
+	"testing"
+
+	"github.com/samber/lo"
+)
+
+func TestSetRequireUnimplemented(t *testing.T) {
+	defer SetRequireUnimplemented(true)
+
+	SetRequireUnimplemented(false)
+	cfg, err := Generator.config("")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cfg.requireUnimplemented {
+		t.Fatal("expected SetRequireUnimplemented(false) to apply to the package-level Generator")
+	}
+
+	cfg, err = Generator.config("require_unimplemented_servers=true")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !cfg.requireUnimplemented {
+		t.Fatal("expected the parameter to take precedence")
+	}
+
+	for _, g := range []generator{
+		NewGenerator(Options{}),
+		NewGenerator(Options{ServerSuffix: "GRPCServer"}),
+		NewFakeGenerator(Options{}).generator,
+	} {
+		cfg, err = g.config("")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !cfg.requireUnimplemented {
+			t.Fatalf("expected %+v to ignore SetRequireUnimplemented", g.Options)
+		}
+	}
+
+	SetRequireUnimplemented(true)
+	cfg, err = NewGenerator(Options{RequireUnimplemented: lo.ToPtr(false)}).config("")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cfg.requireUnimplemented {
+		t.Fatal("expected Options.RequireUnimplemented to take precedence")
+	}
+}
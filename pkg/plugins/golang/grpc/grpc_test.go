@@ -0,0 +1,533 @@
+package grpc_test
+
+import (
+	"testing"
+
+	"github.com/kralicky/ragu"
+	"github.com/kralicky/ragu/internal/gentest"
+	"github.com/kralicky/ragu/pkg/plugins/golang"
+	"github.com/kralicky/ragu/pkg/plugins/golang/gateway"
+	"github.com/kralicky/ragu/pkg/plugins/golang/grpc"
+)
+
+const streamProtos = "../../../../testdata/stream1/*.proto"
+
+// Generic streams require a newer version of grpc than ragu itself.
+const genericStreamsGRPC = "google.golang.org/grpc@v1.64.0"
+
+var streamModes = []struct {
+	name     string
+	opts     grpc.Options
+	requires []string
+}{
+	{"default", grpc.Options{}, nil},
+	{"generic", grpc.Options{GenericStreams: true}, []string{genericStreamsGRPC}},
+}
+
+func generate(t *testing.T, generators ...ragu.Generator) []*ragu.GeneratedFile {
+	t.Helper()
+	out, err := ragu.GenerateCode(append([]ragu.Generator{golang.Generator}, generators...), streamProtos)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return out
+}
+
+func TestStreams(t *testing.T) {
+	for _, mode := range streamModes {
+		mode := mode
+		t.Run(mode.name, func(t *testing.T) {
+			t.Parallel()
+			m := gentest.NewModule(t, mode.requires...)
+			m.WriteGenerated(generate(t, grpc.NewGenerator(mode.opts)))
+			m.WriteFile("testdata/stream1/grpc_test.go", streamsTest)
+			m.Test("./testdata/stream1")
+		})
+	}
+}
+
+func TestFakeStreams(t *testing.T) {
+	for _, mode := range streamModes {
+		mode := mode
+		t.Run(mode.name, func(t *testing.T) {
+			t.Parallel()
+			m := gentest.NewModule(t, mode.requires...)
+			m.WriteGenerated(generate(t, grpc.NewGenerator(mode.opts), grpc.NewFakeGenerator(mode.opts)))
+			m.WriteFile("testdata/stream1/stream1fake/fake_test.go", fakeStreamsTest)
+			m.Test("./testdata/stream1/stream1fake")
+		})
+	}
+}
+
+func TestHarness(t *testing.T) {
+	for _, mode := range []struct {
+		name     string
+		opts     grpc.Options
+		gateway  gateway.Options
+		requires []string
+	}{
+		{"default", grpc.Options{}, gateway.Options{}, nil},
+		{"generic-standalone", grpc.Options{GenericStreams: true}, gateway.Options{Standalone: true}, []string{genericStreamsGRPC}},
+	} {
+		mode := mode
+		t.Run(mode.name, func(t *testing.T) {
+			t.Parallel()
+			m := gentest.NewModule(t, mode.requires...)
+			m.WriteGenerated(generate(t,
+				grpc.NewGenerator(mode.opts),
+				gateway.NewGenerator(mode.gateway),
+				grpc.NewHarnessGenerator(grpc.HarnessOptions{Options: mode.opts, Gateway: &mode.gateway}),
+			))
+			m.WriteFile("testdata/stream1/stream1test/harness_test.go", harnessTest)
+			m.Test("./testdata/stream1/stream1test")
+		})
+	}
+}
+
+// streamsTest calls each kind of method through a real server and client.
+const streamsTest = `package stream1
+
+import (
+	"context"
+	"io"
+	"net"
+	"testing"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/test/bufconn"
+)
+
+type server struct {
+	UnimplementedStreamerServer
+}
+
+func (server) Unary(_ context.Context, in *Item) (*Item, error) {
+	return in, nil
+}
+
+func (server) ServerStream(in *Item, stream Streamer_ServerStreamServer) error {
+	for i := int32(0); i < in.Count; i++ {
+		if err := stream.Send(&Item{Name: in.Name, Count: i}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (server) ClientStream(stream Streamer_ClientStreamServer) error {
+	total := &Item{}
+	for {
+		in, err := stream.Recv()
+		if err == io.EOF {
+			return stream.SendAndClose(total)
+		}
+		if err != nil {
+			return err
+		}
+		total.Name += in.Name
+		total.Count += in.Count
+	}
+}
+
+func (server) BidiStream(stream Streamer_BidiStreamServer) error {
+	for {
+		in, err := stream.Recv()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		if err := stream.Send(in); err != nil {
+			return err
+		}
+	}
+}
+
+func newClient(t *testing.T) StreamerClient {
+	lis := bufconn.Listen(1024 * 1024)
+	srv := grpc.NewServer()
+	RegisterStreamerServer(srv, server{})
+	go srv.Serve(lis)
+	t.Cleanup(srv.Stop)
+
+	conn, err := grpc.DialContext(context.Background(), "bufnet",
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) {
+			return lis.DialContext(ctx)
+		}),
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { conn.Close() })
+	return NewStreamerClient(conn)
+}
+
+func TestStreams(t *testing.T) {
+	ctx := context.Background()
+	client := newClient(t)
+
+	out, err := client.Unary(ctx, &Item{Name: "a"})
+	if err != nil || out.Name != "a" {
+		t.Fatalf("Unary: %v, %v", out, err)
+	}
+
+	ss, err := client.ServerStream(ctx, &Item{Name: "a", Count: 3})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var n int32
+	for ; ; n++ {
+		item, err := ss.Recv()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+		if item.Count != n {
+			t.Fatalf("ServerStream: expected count %d, got %d", n, item.Count)
+		}
+	}
+	if n != 3 {
+		t.Fatalf("ServerStream: expected 3 messages, got %d", n)
+	}
+
+	cs, err := client.ClientStream(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, name := range []string{"a", "b", "c"} {
+		if err := cs.Send(&Item{Name: name, Count: 1}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	total, err := cs.CloseAndRecv()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if total.Name != "abc" || total.Count != 3 {
+		t.Fatalf("ClientStream: unexpected response: %v", total)
+	}
+
+	bs, err := client.BidiStream(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, name := range []string{"a", "b"} {
+		if err := bs.Send(&Item{Name: name}); err != nil {
+			t.Fatal(err)
+		}
+		echo, err := bs.Recv()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if echo.Name != name {
+			t.Fatalf("BidiStream: expected %q, got %q", name, echo.Name)
+		}
+	}
+	if err := bs.CloseSend(); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := bs.Recv(); err != io.EOF {
+		t.Fatalf("BidiStream: expected io.EOF, got %v", err)
+	}
+}
+`
+
+// fakeStreamsTest checks the behavior of the fake client and server streams.
+const fakeStreamsTest = `package stream1fake_test
+
+import (
+	"context"
+	"errors"
+	"io"
+	"testing"
+
+	"github.com/kralicky/ragu/testdata/stream1"
+	"github.com/kralicky/ragu/testdata/stream1/stream1fake"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+func TestFakeClient(t *testing.T) {
+	ctx := context.Background()
+	client := &stream1fake.StreamerClient{}
+	if _, err := client.Unary(ctx, &stream1.Item{}); status.Code(err) != codes.Unimplemented {
+		t.Fatalf("expected codes.Unimplemented, got %v", err)
+	}
+
+	ss := &stream1fake.Streamer_ServerStreamClient{
+		Responses: []*stream1.Item{{Name: "a"}, {Name: "b"}},
+		HeaderMD:  metadata.Pairs("k", "v"),
+	}
+	client.ServerStreamFunc = func(_ context.Context, in *stream1.Item, _ ...grpc.CallOption) (stream1.Streamer_ServerStreamClient, error) {
+		return ss, nil
+	}
+	stream, err := client.ServerStream(ctx, &stream1.Item{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, name := range []string{"a", "b"} {
+		item, err := stream.Recv()
+		if err != nil || item.Name != name {
+			t.Fatalf("Recv: expected %q, got %v, %v", name, item, err)
+		}
+	}
+	if _, err := stream.Recv(); err != io.EOF {
+		t.Fatalf("expected io.EOF, got %v", err)
+	}
+	if md, _ := stream.Header(); md.Get("k")[0] != "v" {
+		t.Fatalf("unexpected header: %v", md)
+	}
+	if stream.Context() == nil {
+		t.Fatal("expected a non-nil context")
+	}
+
+	errRecv := errors.New("recv")
+	cs := &stream1fake.Streamer_ClientStreamClient{Response: &stream1.Item{Name: "total"}}
+	if err := cs.Send(&stream1.Item{Name: "a"}); err != nil {
+		t.Fatal(err)
+	}
+	resp, err := cs.CloseAndRecv()
+	if err != nil || resp.Name != "total" {
+		t.Fatalf("CloseAndRecv: %v, %v", resp, err)
+	}
+	if len(cs.Requests) != 1 || cs.Requests[0].Name != "a" || !cs.Closed {
+		t.Fatalf("unexpected client stream state: %v, closed=%v", cs.Requests, cs.Closed)
+	}
+	cs.RecvErr = errRecv
+	if _, err := cs.CloseAndRecv(); err != errRecv {
+		t.Fatalf("expected RecvErr, got %v", err)
+	}
+
+	bs := &stream1fake.Streamer_BidiStreamClient{Responses: []*stream1.Item{{Name: "x"}}, RecvErr: errRecv}
+	var _ stream1.Streamer_BidiStreamClient = bs
+	if err := bs.Send(&stream1.Item{Name: "a"}); err != nil {
+		t.Fatal(err)
+	}
+	if item, err := bs.Recv(); err != nil || item.Name != "x" {
+		t.Fatalf("Recv: %v, %v", item, err)
+	}
+	if _, err := bs.Recv(); err != errRecv {
+		t.Fatalf("expected RecvErr, got %v", err)
+	}
+	if err := bs.CloseSend(); err != nil || !bs.Closed {
+		t.Fatalf("CloseSend: %v, closed=%v", err, bs.Closed)
+	}
+}
+
+func TestFakeServer(t *testing.T) {
+	srv := &stream1fake.StreamerServer{}
+	if err := srv.BidiStream(&stream1fake.Streamer_BidiStreamServer{}); status.Code(err) != codes.Unimplemented {
+		t.Fatalf("expected codes.Unimplemented, got %v", err)
+	}
+
+	type key struct{}
+	ctx := context.WithValue(context.Background(), key{}, "value")
+	ss := &stream1fake.Streamer_ServerStreamServer{Ctx: ctx}
+	srv.ServerStreamFunc = func(in *stream1.Item, stream stream1.Streamer_ServerStreamServer) error {
+		if stream.Context().Value(key{}) != "value" {
+			t.Error("expected Ctx to be returned from Context")
+		}
+		stream.SetHeader(metadata.Pairs("a", "1"))
+		stream.SendHeader(metadata.Pairs("b", "2"))
+		stream.SetTrailer(metadata.Pairs("c", "3"))
+		for i := int32(0); i < in.Count; i++ {
+			if err := stream.Send(&stream1.Item{Count: i}); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	if err := srv.ServerStream(&stream1.Item{Count: 2}, ss); err != nil {
+		t.Fatal(err)
+	}
+	if len(ss.Sent) != 2 || ss.Sent[1].Count != 1 {
+		t.Fatalf("unexpected messages: %v", ss.Sent)
+	}
+	if len(ss.HeaderMD) != 2 || ss.TrailerMD.Get("c")[0] != "3" {
+		t.Fatalf("unexpected metadata: %v, %v", ss.HeaderMD, ss.TrailerMD)
+	}
+	errSend := errors.New("send")
+	ss.SendErr = errSend
+	if err := srv.ServerStream(&stream1.Item{Count: 1}, ss); err != errSend {
+		t.Fatalf("expected SendErr, got %v", err)
+	}
+
+	cs := &stream1fake.Streamer_ClientStreamServer{Requests: []*stream1.Item{{Count: 1}, {Count: 2}}}
+	srv.ClientStreamFunc = func(stream stream1.Streamer_ClientStreamServer) error {
+		total := &stream1.Item{}
+		for {
+			in, err := stream.Recv()
+			if err == io.EOF {
+				return stream.SendAndClose(total)
+			}
+			if err != nil {
+				return err
+			}
+			total.Count += in.Count
+		}
+	}
+	if err := srv.ClientStream(cs); err != nil {
+		t.Fatal(err)
+	}
+	if cs.Response.Count != 3 {
+		t.Fatalf("unexpected response: %v", cs.Response)
+	}
+
+	errRecv := errors.New("recv")
+	bs := &stream1fake.Streamer_BidiStreamServer{Requests: []*stream1.Item{{Name: "a"}}, RecvErr: errRecv}
+	srv.BidiStreamFunc = func(stream stream1.Streamer_BidiStreamServer) error {
+		for {
+			in, err := stream.Recv()
+			if err != nil {
+				return err
+			}
+			if err := stream.Send(in); err != nil {
+				return err
+			}
+		}
+	}
+	if err := srv.BidiStream(bs); err != errRecv {
+		t.Fatalf("expected RecvErr, got %v", err)
+	}
+	if len(bs.Sent) != 1 || bs.Sent[0].Name != "a" {
+		t.Fatalf("unexpected messages: %v", bs.Sent)
+	}
+}
+`
+
+// harnessTest calls the harness's server through its client and gateway.
+const harnessTest = `package stream1test_test
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"testing"
+
+	"github.com/kralicky/ragu/testdata/stream1"
+	"github.com/kralicky/ragu/testdata/stream1/stream1test"
+)
+
+type server struct {
+	stream1.UnimplementedStreamerServer
+}
+
+func (server) Unary(_ context.Context, in *stream1.Item) (*stream1.Item, error) {
+	return &stream1.Item{Name: in.Name, Count: 1}, nil
+}
+
+func (server) ServerStream(in *stream1.Item, stream stream1.Streamer_ServerStreamServer) error {
+	for i := int32(0); i < 2; i++ {
+		if err := stream.Send(&stream1.Item{Name: in.Name, Count: i}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (server) BidiStream(stream stream1.Streamer_BidiStreamServer) error {
+	for {
+		in, err := stream.Recv()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		if err := stream.Send(in); err != nil {
+			return err
+		}
+	}
+}
+
+func TestClient(t *testing.T) {
+	ctx := context.Background()
+	h := stream1test.NewStreamerHarness(t, server{})
+
+	out, err := h.Client.Unary(ctx, &stream1.Item{Name: "a"})
+	if err != nil || out.Name != "a" {
+		t.Fatalf("Unary: %v, %v", out, err)
+	}
+
+	ss, err := h.Client.ServerStream(ctx, &stream1.Item{Name: "a"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var n int
+	for ; ; n++ {
+		if _, err := ss.Recv(); err == io.EOF {
+			break
+		} else if err != nil {
+			t.Fatal(err)
+		}
+	}
+	if n != 2 {
+		t.Fatalf("ServerStream: expected 2 messages, got %d", n)
+	}
+
+	bs, err := h.Client.BidiStream(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := bs.Send(&stream1.Item{Name: "b"}); err != nil {
+		t.Fatal(err)
+	}
+	if echo, err := bs.Recv(); err != nil || echo.Name != "b" {
+		t.Fatalf("BidiStream: %v, %v", echo, err)
+	}
+	bs.CloseSend()
+	if _, err := bs.Recv(); err != io.EOF {
+		t.Fatalf("BidiStream: expected io.EOF, got %v", err)
+	}
+}
+
+func TestGateway(t *testing.T) {
+	h := stream1test.NewStreamerHarness(t, server{})
+
+	resp, err := http.Get(h.Gateway.URL + "/v1/items/a")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	var item struct {
+		Name  string
+		Count int
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&item); err != nil {
+		t.Fatal(err)
+	}
+	if resp.StatusCode != http.StatusOK || item.Name != "a" || item.Count != 1 {
+		t.Fatalf("unexpected response: %d %+v", resp.StatusCode, item)
+	}
+
+	resp, err = http.Get(h.Gateway.URL + "/v1/watch/a")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	var n int
+	for scanner := bufio.NewScanner(resp.Body); scanner.Scan(); n++ {
+		var msg struct {
+			Result struct{ Name string }
+		}
+		if err := json.Unmarshal(scanner.Bytes(), &msg); err != nil {
+			t.Fatal(err)
+		}
+		if msg.Result.Name != "a" {
+			t.Fatalf("unexpected message: %s", scanner.Text())
+		}
+	}
+	if n != 2 {
+		t.Fatalf("expected 2 streamed messages, got %d", n)
+	}
+}
+`
@@ -0,0 +1,156 @@
+package grpc
+
+import (
+	"path"
+
+	"github.com/kralicky/ragu/pkg/plugins/golang/gateway"
+	"google.golang.org/protobuf/compiler/protogen"
+)
+
+const (
+	netPackage      = protogen.GoImportPath("net")
+	httptestPackage = protogen.GoImportPath("net/http/httptest")
+	testingPackage  = protogen.GoImportPath("testing")
+	bufconnPackage  = protogen.GoImportPath("google.golang.org/grpc/test/bufconn")
+	insecurePackage = protogen.GoImportPath("google.golang.org/grpc/credentials/insecure")
+	runtimePackage  = protogen.GoImportPath("github.com/kralicky/grpc-gateway/v2/runtime")
+)
+
+var HarnessGenerator = harnessGenerator{}
+
+type HarnessOptions struct {
+	// Options are the options of the go-grpc generator, which must match
+	// those used to generate the services.
+	Options
+	// Gateway enables serving the grpc-gateway handlers of services with HTTP
+	// bindings. The options must match those of the go-grpc-gateway
+	// generator, so that the harness refers to the generated Register
+	// functions.
+	Gateway *gateway.Options
+}
+
+// NewHarnessGenerator returns a go-grpc-harness generator with the given
+// options.
+func NewHarnessGenerator(opts HarnessOptions) harnessGenerator {
+	return harnessGenerator{generator: generator{Options: opts.Options}, gateway: opts.Gateway}
+}
+
+// harnessGenerator generates test helpers which serve a service
+// implementation on an in-memory grpc.Server, and return a client connected
+// to it. The helpers are placed in a "<package>test" subpackage.
+type harnessGenerator struct {
+	generator
+	gateway *gateway.Options
+}
+
+func (harnessGenerator) Name() string {
+	return "go-grpc-harness"
+}
+
+func (g harnessGenerator) Generate(gen *protogen.Plugin) error {
+	cfg, err := g.config(gen.Request.GetParameter())
+	if err != nil {
+		return err
+	}
+	var registerFuncs map[*protogen.Service]protogen.GoIdent
+	if g.gateway != nil {
+		registerFuncs, err = gateway.RegisterFuncs(gen, *g.gateway)
+		if err != nil {
+			return err
+		}
+	}
+	for _, f := range gen.Files {
+		if f.Generate {
+			generateHarnessFile(gen, f, cfg, registerFuncs)
+		}
+	}
+	return nil
+}
+
+// generateHarnessFile generates a _grpc_harness.pb.go file containing test
+// harnesses for the services in the file.
+func generateHarnessFile(gen *protogen.Plugin, file *protogen.File, cfg config, registerFuncs map[*protogen.Service]protogen.GoIdent) *protogen.GeneratedFile {
+	if len(file.Services) == 0 {
+		return nil
+	}
+	pkgName := string(file.GoPackageName) + "test"
+	filename := path.Join(path.Dir(file.GeneratedFilenamePrefix), pkgName, path.Base(file.GeneratedFilenamePrefix)+"_grpc_harness.pb.go")
+	g := gen.NewGeneratedFile(filename, protogen.GoImportPath(path.Join(string(file.GoImportPath), pkgName)))
+	g.P("// Code generated by protoc-gen-go-grpc-harness. DO NOT EDIT.")
+	g.P("// source: ", file.Desc.Path())
+	g.P()
+	g.P("package ", pkgName)
+	g.P()
+	for _, service := range file.Services {
+		registerFunc, ok := registerFuncs[service]
+		genHarness(g, file, service, cfg, registerFunc, ok)
+	}
+	return g
+}
+
+func genHarness(g *protogen.GeneratedFile, file *protogen.File, service *protogen.Service, cfg config, registerFunc protogen.GoIdent, withGateway bool) {
+	clientName := cfg.clientName(service)
+	serverName := cfg.serverName(service)
+	harnessName := service.GoName + "Harness"
+
+	g.P("// ", harnessName, " is an in-memory ", service.GoName, " server and a client connected")
+	g.P("// to it, created by New", harnessName, ".")
+	g.P("type ", harnessName, " struct {")
+	g.P("Client ", file.GoImportPath.Ident(clientName))
+	g.P("Conn *", grpcPackage.Ident("ClientConn"))
+	g.P("Server *", grpcPackage.Ident("Server"))
+	if withGateway {
+		g.P("// Gateway serves the HTTP gateway of the service, proxying requests")
+		g.P("// to Server.")
+		g.P("Gateway *", httptestPackage.Ident("Server"))
+	}
+	g.P("}")
+	g.P()
+	g.P("// New", harnessName, " serves srv on a grpc.Server listening on an in-memory")
+	g.P("// connection, and returns a client connected to it. The server and client")
+	g.P("// are stopped when the test completes.")
+	g.P("func New", harnessName, "(tb ", testingPackage.Ident("TB"), ", srv ", file.GoImportPath.Ident(serverName), ", opts ...", grpcPackage.Ident("ServerOption"), ") *", harnessName, " {")
+	g.P("tb.Helper()")
+	g.P("lis := ", bufconnPackage.Ident("Listen"), "(1024 * 1024)")
+	g.P("server := ", grpcPackage.Ident("NewServer"), "(opts...)")
+	g.P(file.GoImportPath.Ident(cfg.registerName(service)), "(server, srv)")
+	g.P("go func() { _ = server.Serve(lis) }()")
+	g.P("tb.Cleanup(server.Stop)")
+	g.P()
+	// grpc.Dial is deprecated in favor of grpc.NewClient, which is only
+	// available in the versions of grpc required by SupportPackageIsVersion9.
+	// The passthrough resolver hands the target directly to the dialer, like
+	// grpc.Dial does.
+	if cfg.supportPackageVersion >= 9 {
+		g.P("conn, err := ", grpcPackage.Ident("NewClient"), `("passthrough:///bufnet",`)
+	} else {
+		g.P("conn, err := ", grpcPackage.Ident("Dial"), `("bufnet",`)
+	}
+	g.P(grpcPackage.Ident("WithContextDialer"), "(func(ctx ", contextPackage.Ident("Context"), ", _ string) (", netPackage.Ident("Conn"), ", error) {")
+	g.P("return lis.DialContext(ctx)")
+	g.P("}),")
+	g.P(grpcPackage.Ident("WithTransportCredentials"), "(", insecurePackage.Ident("NewCredentials"), "()),")
+	g.P(")")
+	g.P("if err != nil {")
+	g.P(`tb.Fatalf("failed to connect to in-memory server: %v", err)`)
+	g.P("}")
+	g.P("tb.Cleanup(func() { _ = conn.Close() })")
+	g.P()
+	g.P("h := &", harnessName, "{")
+	g.P("Client: ", file.GoImportPath.Ident("New"+clientName), "(conn),")
+	g.P("Conn: conn,")
+	g.P("Server: server,")
+	g.P("}")
+	if withGateway {
+		g.P()
+		g.P("mux := ", runtimePackage.Ident("NewServeMux"), "()")
+		g.P("if err := ", registerFunc, "(", contextPackage.Ident("Background"), "(), mux, conn); err != nil {")
+		g.P(`tb.Fatalf("failed to register gateway handlers: %v", err)`)
+		g.P("}")
+		g.P("h.Gateway = ", httptestPackage.Ident("NewServer"), "(mux)")
+		g.P("tb.Cleanup(h.Gateway.Close)")
+	}
+	g.P("return h")
+	g.P("}")
+	g.P()
+}
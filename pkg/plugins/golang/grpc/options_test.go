@@ -0,0 +1,137 @@
+package grpc_test
+
+import (
+	"testing"
+
+	"github.com/kralicky/ragu/internal/gentest"
+	"github.com/kralicky/ragu/pkg/plugins/golang/grpc"
+	"github.com/samber/lo"
+)
+
+// TestOptions builds the generated code with each set of options, together
+// with a test that checks the generated API.
+func TestOptions(t *testing.T) {
+	for _, tc := range []struct {
+		name     string
+		opts     grpc.Options
+		requires []string
+		test     string
+	}{
+		{
+			name: "default",
+			test: `
+var _ StreamerClient = NewStreamerClient(nil)
+var _ func(grpc.ServiceRegistrar, StreamerServer) = RegisterStreamerServer
+
+func TestOptions(t *testing.T) {
+	if _, ok := reflect.TypeOf((*StreamerServer)(nil)).Elem().MethodByName("mustEmbedUnimplementedStreamerServer"); !ok {
+		t.Fatal("expected servers to be required to embed UnimplementedStreamerServer")
+	}
+}
+`,
+		},
+		{
+			name: "no-require-unimplemented",
+			opts: grpc.Options{RequireUnimplemented: lo.ToPtr(false)},
+			test: `
+func TestOptions(t *testing.T) {
+	if n := reflect.TypeOf((*StreamerServer)(nil)).Elem().NumMethod(); n != 4 {
+		t.Fatalf("expected StreamerServer to only contain the service methods, got %d methods", n)
+	}
+	var _ grpc.ServiceRegistrar
+}
+`,
+		},
+		{
+			name: "parameter",
+			opts: grpc.Options{Opt: "require_unimplemented_servers=false", RequireUnimplemented: lo.ToPtr(true)},
+			test: `
+func TestOptions(t *testing.T) {
+	if n := reflect.TypeOf((*StreamerServer)(nil)).Elem().NumMethod(); n != 4 {
+		t.Fatalf("expected the parameter to take precedence, got %d methods", n)
+	}
+	var _ grpc.ServiceRegistrar
+}
+`,
+		},
+		{
+			name: "suffixes",
+			opts: grpc.Options{ClientSuffix: "GRPCClient", ServerSuffix: "GRPCServer"},
+			test: `
+var _ StreamerGRPCClient = NewStreamerGRPCClient(nil)
+var _ StreamerGRPCServer = UnimplementedStreamerGRPCServer{}
+var _ func(grpc.ServiceRegistrar, StreamerGRPCServer) = RegisterStreamerGRPCServer
+
+func TestOptions(t *testing.T) {
+	if _, ok := reflect.TypeOf((*StreamerGRPCServer)(nil)).Elem().MethodByName("mustEmbedUnimplementedStreamerGRPCServer"); !ok {
+		t.Fatal("expected servers to be required to embed UnimplementedStreamerGRPCServer")
+	}
+}
+`,
+		},
+		{
+			name:     "support-package-version",
+			opts:     grpc.Options{SupportPackageVersion: 8},
+			requires: []string{"google.golang.org/grpc@v1.62.0"},
+			test: `
+var _ StreamerClient = NewStreamerClient(nil)
+
+func TestOptions(t *testing.T) {
+	if reflect.TypeOf((*Streamer_ServerStreamClient)(nil)).Elem().Kind() != reflect.Interface {
+		t.Fatal("expected the named stream interfaces to be generated")
+	}
+	var _ grpc.ServiceRegistrar
+}
+`,
+		},
+		{
+			name:     "generic-streams",
+			opts:     grpc.Options{GenericStreams: true},
+			requires: []string{genericStreamsGRPC},
+			test:     genericStreamsOptionsTest,
+		},
+		{
+			name:     "generic-streams-param",
+			opts:     grpc.Options{Opt: "use_generic_streams_experimental=true"},
+			requires: []string{genericStreamsGRPC},
+			test:     genericStreamsOptionsTest,
+		},
+	} {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			m := gentest.NewModule(t, tc.requires...)
+			m.WriteGenerated(generate(t, grpc.NewGenerator(tc.opts)))
+			m.WriteFile("testdata/stream1/options_test.go", optionsTestHeader+tc.test)
+			m.Test("./testdata/stream1")
+		})
+	}
+}
+
+const optionsTestHeader = `package stream1
+
+import (
+	"reflect"
+	"testing"
+
+	"google.golang.org/grpc"
+)
+`
+
+// genericStreamsOptionsTest checks that the named stream types are aliases of
+// the generic stream types.
+const genericStreamsOptionsTest = `
+var (
+	_ grpc.ServerStreamingClient[Item]       = Streamer_ServerStreamClient(nil)
+	_ grpc.ClientStreamingServer[Item, Item] = Streamer_ClientStreamServer(nil)
+	_ grpc.BidiStreamingClient[Item, Item]   = Streamer_BidiStreamClient(nil)
+
+	_ func(StreamerServer, *Item, grpc.ServerStreamingServer[Item]) error = StreamerServer.ServerStream
+)
+
+func TestOptions(t *testing.T) {
+	if reflect.TypeOf((*Streamer_BidiStreamServer)(nil)) != reflect.TypeOf((*grpc.BidiStreamingServer[Item, Item])(nil)) {
+		t.Fatal("expected Streamer_BidiStreamServer to be an alias")
+	}
+}
+`
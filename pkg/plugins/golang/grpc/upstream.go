@@ -40,8 +40,8 @@ type serviceGenerateHelperInterface interface {
 	genFullMethods(g *protogen.GeneratedFile, service *protogen.Service)
 	generateClientStruct(g *protogen.GeneratedFile, clientName string)
 	generateNewClientDefinitions(g *protogen.GeneratedFile, service *protogen.Service, clientName string)
-	generateUnimplementedServerType(gen *protogen.Plugin, file *protogen.File, g *protogen.GeneratedFile, service *protogen.Service)
-	generateServerFunctions(gen *protogen.Plugin, file *protogen.File, g *protogen.GeneratedFile, service *protogen.Service, serverType string, serviceDescVar string)
+	generateUnimplementedServerType(gen *protogen.Plugin, file *protogen.File, g *protogen.GeneratedFile, service *protogen.Service, cfg config)
+	generateServerFunctions(gen *protogen.Plugin, file *protogen.File, g *protogen.GeneratedFile, service *protogen.Service, serverType string, serviceDescVar string, cfg config)
 	formatHandlerFuncName(service *protogen.Service, hname string) string
 }
 
@@ -73,14 +73,19 @@ func (serviceGenerateHelper) generateNewClientDefinitions(g *protogen.GeneratedF
 	g.P("return &", unexport(clientName), "{cc}")
 }
 
-func (serviceGenerateHelper) generateUnimplementedServerType(gen *protogen.Plugin, file *protogen.File, g *protogen.GeneratedFile, service *protogen.Service) {
-	serverType := service.GoName + "Server"
+func (serviceGenerateHelper) generateUnimplementedServerType(gen *protogen.Plugin, file *protogen.File, g *protogen.GeneratedFile, service *protogen.Service, cfg config) {
+	serverType := cfg.serverName(service)
 	mustOrShould := "must"
-	if !*requireUnimplemented {
+	if !cfg.requireUnimplemented {
 		mustOrShould = "should"
 	}
 	// Server Unimplemented struct for forward compatibility.
 	g.P("// Unimplemented", serverType, " ", mustOrShould, " be embedded to have forward compatible implementations.")
+	if cfg.genericStreams {
+		g.P("//")
+		g.P("// NOTE: this should be embedded by value instead of pointer to avoid a nil")
+		g.P("// pointer dereference when methods are called.")
+	}
 	g.P("type Unimplemented", serverType, " struct {")
 	g.P("}")
 	g.P()
@@ -89,21 +94,24 @@ func (serviceGenerateHelper) generateUnimplementedServerType(gen *protogen.Plugi
 		if !method.Desc.IsStreamingClient() && !method.Desc.IsStreamingServer() {
 			nilArg = "nil,"
 		}
-		g.P("func (Unimplemented", serverType, ") ", serverSignature(g, method), "{")
+		g.P("func (Unimplemented", serverType, ") ", serverSignature(g, file, method, cfg), "{")
 		g.P("return ", nilArg, statusPackage.Ident("Errorf"), "(", codesPackage.Ident("Unimplemented"), `, "method `, method.GoName, ` not implemented")`)
 		g.P("}")
 	}
-	if *requireUnimplemented {
+	if cfg.requireUnimplemented {
 		g.P("func (Unimplemented", serverType, ") mustEmbedUnimplemented", serverType, "() {}")
 	}
+	if cfg.genericStreams {
+		g.P("func (Unimplemented", serverType, ") testEmbeddedByValue() {}")
+	}
 	g.P()
 }
 
-func (serviceGenerateHelper) generateServerFunctions(gen *protogen.Plugin, file *protogen.File, g *protogen.GeneratedFile, service *protogen.Service, serverType string, serviceDescVar string) {
+func (serviceGenerateHelper) generateServerFunctions(gen *protogen.Plugin, file *protogen.File, g *protogen.GeneratedFile, service *protogen.Service, serverType string, serviceDescVar string, cfg config) {
 	// Server handler implementations.
 	handlerNames := make([]string, 0, len(service.Methods))
 	for _, method := range service.Methods {
-		hname := genServerMethod(gen, file, g, method, func(hname string) string {
+		hname := genServerMethod(gen, file, g, method, cfg, func(hname string) string {
 			return hname
 		})
 		handlerNames = append(handlerNames, hname)
@@ -124,7 +132,7 @@ const fileDescriptorProtoPackageFieldNumber = 2
 const fileDescriptorProtoSyntaxFieldNumber = 12
 
 // generateFile generates a _grpc.pb.go file containing gRPC service definitions.
-func generateFile(gen *protogen.Plugin, file *protogen.File) *protogen.GeneratedFile {
+func generateFile(gen *protogen.Plugin, file *protogen.File, cfg config) *protogen.GeneratedFile {
 	if len(file.Services) == 0 {
 		return nil
 	}
@@ -134,7 +142,11 @@ func generateFile(gen *protogen.Plugin, file *protogen.File) *protogen.Generated
 	genLeadingComments(g, file.Desc.SourceLocations().ByPath(protoreflect.SourcePath{fileDescriptorProtoSyntaxFieldNumber}))
 	g.P("// Code generated by protoc-gen-go-grpc. DO NOT EDIT.")
 	g.P("// versions:")
-	g.P("// - protoc-gen-go-grpc v", version)
+	if cfg.genericStreams {
+		g.P("// - protoc-gen-go-grpc v", genericStreamsVersion)
+	} else {
+		g.P("// - protoc-gen-go-grpc v", version)
+	}
 	g.P("// - ragu               ", protocVersion(gen))
 	if file.Proto.GetOptions().GetDeprecated() {
 		g.P("// ", file.Desc.Path(), " is a deprecated file.")
@@ -146,7 +158,7 @@ func generateFile(gen *protogen.Plugin, file *protogen.File) *protogen.Generated
 	genLeadingComments(g, file.Desc.SourceLocations().ByPath(protoreflect.SourcePath{fileDescriptorProtoPackageFieldNumber}))
 	g.P("package ", file.GoPackageName)
 	g.P()
-	generateFileContent(gen, file, g)
+	generateFileContent(gen, file, g, cfg)
 	return g
 }
 
@@ -163,27 +175,27 @@ func protocVersion(gen *protogen.Plugin) string {
 }
 
 // generateFileContent generates the gRPC service definitions, excluding the package statement.
-func generateFileContent(gen *protogen.Plugin, file *protogen.File, g *protogen.GeneratedFile) {
+func generateFileContent(gen *protogen.Plugin, file *protogen.File, g *protogen.GeneratedFile, cfg config) {
 	if len(file.Services) == 0 {
 		return
 	}
 
 	g.P("// This is a compile-time assertion to ensure that this generated file")
 	g.P("// is compatible with the grpc package it is being compiled against.")
-	g.P("// Requires gRPC-Go v1.32.0 or later.")
-	g.P("const _ = ", grpcPackage.Ident("SupportPackageIsVersion7")) // When changing, update version number above.
+	g.P("// Requires gRPC-Go ", supportPackageVersions[cfg.supportPackageVersion], " or later.")
+	g.P("const _ = ", grpcPackage.Ident(fmt.Sprintf("SupportPackageIsVersion%d", cfg.supportPackageVersion)))
 	g.P()
 	for _, service := range file.Services {
-		genService(gen, file, g, service)
+		genService(gen, file, g, service, cfg)
 	}
 }
 
-func genService(gen *protogen.Plugin, file *protogen.File, g *protogen.GeneratedFile, service *protogen.Service) {
+func genService(gen *protogen.Plugin, file *protogen.File, g *protogen.GeneratedFile, service *protogen.Service, cfg config) {
 	// Full methods constants.
 	helper.genFullMethods(g, service)
 
 	// Client interface.
-	clientName := service.GoName + "Client"
+	clientName := cfg.clientName(service)
 
 	g.P("// ", clientName, " is the client API for ", service.GoName, " service.")
 	g.P("//")
@@ -201,7 +213,7 @@ func genService(gen *protogen.Plugin, file *protogen.File, g *protogen.Generated
 			g.P(deprecationComment)
 		}
 		g.P(method.Comments.Leading,
-			clientSignature(g, method))
+			clientSignature(g, file, method, cfg))
 	}
 	g.P("}")
 	g.P()
@@ -223,22 +235,22 @@ func genService(gen *protogen.Plugin, file *protogen.File, g *protogen.Generated
 	for _, method := range service.Methods {
 		if !method.Desc.IsStreamingServer() && !method.Desc.IsStreamingClient() {
 			// Unary RPC method
-			genClientMethod(gen, file, g, method, methodIndex)
+			genClientMethod(gen, file, g, method, methodIndex, cfg)
 			methodIndex++
 		} else {
 			// Streaming RPC method
-			genClientMethod(gen, file, g, method, streamIndex)
+			genClientMethod(gen, file, g, method, streamIndex, cfg)
 			streamIndex++
 		}
 	}
 
 	mustOrShould := "must"
-	if !*requireUnimplemented {
+	if !cfg.requireUnimplemented {
 		mustOrShould = "should"
 	}
 
 	// Server interface.
-	serverType := service.GoName + "Server"
+	serverType := cfg.serverName(service)
 	g.P("// ", serverType, " is the server API for ", service.GoName, " service.")
 	g.P("// All implementations ", mustOrShould, " embed Unimplemented", serverType)
 	g.P("// for forward compatibility")
@@ -254,16 +266,16 @@ func genService(gen *protogen.Plugin, file *protogen.File, g *protogen.Generated
 			g.P(deprecationComment)
 		}
 		g.P(method.Comments.Leading,
-			serverSignature(g, method))
+			serverSignature(g, file, method, cfg))
 	}
-	if *requireUnimplemented {
+	if cfg.requireUnimplemented {
 		g.P("mustEmbedUnimplemented", serverType, "()")
 	}
 	g.P("}")
 	g.P()
 
 	// Server Unimplemented struct for forward compatibility.
-	helper.generateUnimplementedServerType(gen, file, g, service)
+	helper.generateUnimplementedServerType(gen, file, g, service, cfg)
 
 	// Unsafe Server interface to opt-out of forward compatibility.
 	g.P("// Unsafe", serverType, " may be embedded to opt out of forward compatibility for this service.")
@@ -278,15 +290,24 @@ func genService(gen *protogen.Plugin, file *protogen.File, g *protogen.Generated
 		g.P(deprecationComment)
 	}
 	serviceDescVar := service.GoName + "_ServiceDesc"
-	g.P("func Register", service.GoName, "Server(s ", grpcPackage.Ident("ServiceRegistrar"), ", srv ", serverType, ") {")
+	g.P("func ", cfg.registerName(service), "(s ", grpcPackage.Ident("ServiceRegistrar"), ", srv ", serverType, ") {")
+	if cfg.genericStreams {
+		g.P("// If the following call panics, it indicates Unimplemented", serverType, " was")
+		g.P("// embedded by pointer and is nil.  This will cause panics if an")
+		g.P("// unimplemented method is ever invoked, so we test this at initialization")
+		g.P("// time to prevent it from happening at runtime later due to I/O.")
+		g.P("if t, ok := srv.(interface{ testEmbeddedByValue() }); ok {")
+		g.P("t.testEmbeddedByValue()")
+		g.P("}")
+	}
 	g.P("s.RegisterService(&", serviceDescVar, `, srv)`)
 	g.P("}")
 	g.P()
 
-	helper.generateServerFunctions(gen, file, g, service, serverType, serviceDescVar)
+	helper.generateServerFunctions(gen, file, g, service, serverType, serviceDescVar, cfg)
 }
 
-func clientSignature(g *protogen.GeneratedFile, method *protogen.Method) string {
+func clientSignature(g *protogen.GeneratedFile, file *protogen.File, method *protogen.Method, cfg config) string {
 	s := method.GoName + "(ctx " + g.QualifiedGoIdent(contextPackage.Ident("Context"))
 	if !method.Desc.IsStreamingClient() {
 		s += ", in *" + g.QualifiedGoIdent(method.Input.GoIdent)
@@ -295,23 +316,37 @@ func clientSignature(g *protogen.GeneratedFile, method *protogen.Method) string
 	if !method.Desc.IsStreamingClient() && !method.Desc.IsStreamingServer() {
 		s += "*" + g.QualifiedGoIdent(method.Output.GoIdent)
 	} else {
-		s += method.Parent.GoName + "_" + method.GoName + "Client"
+		s += clientStreamType(g, file, method, cfg)
 	}
 	s += ", error)"
 	return s
 }
 
-func genClientMethod(gen *protogen.Plugin, file *protogen.File, g *protogen.GeneratedFile, method *protogen.Method, index int) {
+// clientStreamType returns the type of the client side of a streaming
+// method, qualified relative to g.
+func clientStreamType(g *protogen.GeneratedFile, file *protogen.File, method *protogen.Method, cfg config) string {
+	if cfg.genericStreams {
+		return genericClientStreamType(g, method)
+	}
+	return g.QualifiedGoIdent(file.GoImportPath.Ident(method.Parent.GoName + "_" + method.GoName + "Client"))
+}
+
+func genClientMethod(gen *protogen.Plugin, file *protogen.File, g *protogen.GeneratedFile, method *protogen.Method, index int, cfg config) {
 	service := method.Parent
 	fmSymbol := helper.formatFullMethodSymbol(service, method)
 
 	if method.Desc.Options().(*descriptorpb.MethodOptions).GetDeprecated() {
 		g.P(deprecationComment)
 	}
-	g.P("func (c *", unexport(service.GoName), "Client) ", clientSignature(g, method), "{")
+	g.P("func (c *", unexport(cfg.clientName(service)), ") ", clientSignature(g, file, method, cfg), "{")
+	optsVar := "opts"
+	if cfg.supportPackageVersion >= 8 {
+		g.P("cOpts := append([]", grpcPackage.Ident("CallOption"), "{", grpcPackage.Ident("StaticMethod"), "()}, opts...)")
+		optsVar = "cOpts"
+	}
 	if !method.Desc.IsStreamingServer() && !method.Desc.IsStreamingClient() {
 		g.P("out := new(", method.Output.GoIdent, ")")
-		g.P(`err := c.cc.Invoke(ctx, `, fmSymbol, `, in, out, opts...)`)
+		g.P(`err := c.cc.Invoke(ctx, `, fmSymbol, `, in, out, `, optsVar, `...)`)
 		g.P("if err != nil { return nil, err }")
 		g.P("return out, nil")
 		g.P("}")
@@ -320,9 +355,13 @@ func genClientMethod(gen *protogen.Plugin, file *protogen.File, g *protogen.Gene
 	}
 	streamType := unexport(service.GoName) + method.GoName + "Client"
 	serviceDescVar := service.GoName + "_ServiceDesc"
-	g.P("stream, err := c.cc.NewStream(ctx, &", serviceDescVar, ".Streams[", index, `], `, fmSymbol, `, opts...)`)
+	g.P("stream, err := c.cc.NewStream(ctx, &", serviceDescVar, ".Streams[", index, `], `, fmSymbol, `, `, optsVar, `...)`)
 	g.P("if err != nil { return nil, err }")
-	g.P("x := &", streamType, "{stream}")
+	if cfg.genericStreams {
+		g.P("x := &", grpcPackage.Ident("GenericClientStream"), "[", method.Input.GoIdent, ", ", method.Output.GoIdent, "]{ClientStream: stream}")
+	} else {
+		g.P("x := &", streamType, "{stream}")
+	}
 	if !method.Desc.IsStreamingClient() {
 		g.P("if err := x.ClientStream.SendMsg(in); err != nil { return nil, err }")
 		g.P("if err := x.ClientStream.CloseSend(); err != nil { return nil, err }")
@@ -331,6 +370,14 @@ func genClientMethod(gen *protogen.Plugin, file *protogen.File, g *protogen.Gene
 	g.P("}")
 	g.P()
 
+	if cfg.genericStreams {
+		// Type alias for the generic stream, in place of the auxiliary types below.
+		g.P("// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.")
+		g.P("type ", service.GoName, "_", method.GoName, "Client = ", genericClientStreamType(g, method))
+		g.P()
+		return
+	}
+
 	genSend := method.Desc.IsStreamingClient()
 	genRecv := method.Desc.IsStreamingServer()
 	genCloseAndRecv := !method.Desc.IsStreamingServer()
@@ -380,7 +427,7 @@ func genClientMethod(gen *protogen.Plugin, file *protogen.File, g *protogen.Gene
 	}
 }
 
-func serverSignature(g *protogen.GeneratedFile, method *protogen.Method) string {
+func serverSignature(g *protogen.GeneratedFile, file *protogen.File, method *protogen.Method, cfg config) string {
 	var reqArgs []string
 	ret := "error"
 	if !method.Desc.IsStreamingClient() && !method.Desc.IsStreamingServer() {
@@ -391,11 +438,20 @@ func serverSignature(g *protogen.GeneratedFile, method *protogen.Method) string
 		reqArgs = append(reqArgs, "*"+g.QualifiedGoIdent(method.Input.GoIdent))
 	}
 	if method.Desc.IsStreamingClient() || method.Desc.IsStreamingServer() {
-		reqArgs = append(reqArgs, method.Parent.GoName+"_"+method.GoName+"Server")
+		reqArgs = append(reqArgs, serverStreamType(g, file, method, cfg))
 	}
 	return method.GoName + "(" + strings.Join(reqArgs, ", ") + ") " + ret
 }
 
+// serverStreamType returns the type of the server side of a streaming
+// method, qualified relative to g.
+func serverStreamType(g *protogen.GeneratedFile, file *protogen.File, method *protogen.Method, cfg config) string {
+	if cfg.genericStreams {
+		return genericServerStreamType(g, method)
+	}
+	return g.QualifiedGoIdent(file.GoImportPath.Ident(method.Parent.GoName + "_" + method.GoName + "Server"))
+}
+
 func genServiceDesc(file *protogen.File, g *protogen.GeneratedFile, serviceDescVar string, serverType string, service *protogen.Service, handlerNames []string) {
 	// Service descriptor.
 	g.P("// ", serviceDescVar, " is the ", grpcPackage.Ident("ServiceDesc"), " for ", service.GoName, " service.")
@@ -437,7 +493,7 @@ func genServiceDesc(file *protogen.File, g *protogen.GeneratedFile, serviceDescV
 	g.P()
 }
 
-func genServerMethod(gen *protogen.Plugin, file *protogen.File, g *protogen.GeneratedFile, method *protogen.Method, hnameFuncNameFormatter func(string) string) string {
+func genServerMethod(gen *protogen.Plugin, file *protogen.File, g *protogen.GeneratedFile, method *protogen.Method, cfg config, hnameFuncNameFormatter func(string) string) string {
 	service := method.Parent
 	hname := fmt.Sprintf("_%s_%s_Handler", service.GoName, method.GoName)
 
@@ -445,14 +501,14 @@ func genServerMethod(gen *protogen.Plugin, file *protogen.File, g *protogen.Gene
 		g.P("func ", hnameFuncNameFormatter(hname), "(srv interface{}, ctx ", contextPackage.Ident("Context"), ", dec func(interface{}) error, interceptor ", grpcPackage.Ident("UnaryServerInterceptor"), ") (interface{}, error) {")
 		g.P("in := new(", method.Input.GoIdent, ")")
 		g.P("if err := dec(in); err != nil { return nil, err }")
-		g.P("if interceptor == nil { return srv.(", service.GoName, "Server).", method.GoName, "(ctx, in) }")
+		g.P("if interceptor == nil { return srv.(", cfg.serverName(service), ").", method.GoName, "(ctx, in) }")
 		g.P("info := &", grpcPackage.Ident("UnaryServerInfo"), "{")
 		g.P("Server: srv,")
 		fmSymbol := helper.formatFullMethodSymbol(service, method)
 		g.P("FullMethod: ", fmSymbol, ",")
 		g.P("}")
 		g.P("handler := func(ctx ", contextPackage.Ident("Context"), ", req interface{}) (interface{}, error) {")
-		g.P("return srv.(", service.GoName, "Server).", method.GoName, "(ctx, req.(*", method.Input.GoIdent, "))")
+		g.P("return srv.(", cfg.serverName(service), ").", method.GoName, "(ctx, req.(*", method.Input.GoIdent, "))")
 		g.P("}")
 		g.P("return interceptor(ctx, in, info, handler)")
 		g.P("}")
@@ -460,17 +516,30 @@ func genServerMethod(gen *protogen.Plugin, file *protogen.File, g *protogen.Gene
 		return hname
 	}
 	streamType := unexport(service.GoName) + method.GoName + "Server"
+	streamValue := "&" + streamType + "{stream}"
+	if cfg.genericStreams {
+		streamValue = "&" + g.QualifiedGoIdent(grpcPackage.Ident("GenericServerStream")) + "[" +
+			g.QualifiedGoIdent(method.Input.GoIdent) + ", " + g.QualifiedGoIdent(method.Output.GoIdent) + "]{ServerStream: stream}"
+	}
 	g.P("func ", hnameFuncNameFormatter(hname), "(srv interface{}, stream ", grpcPackage.Ident("ServerStream"), ") error {")
 	if !method.Desc.IsStreamingClient() {
 		g.P("m := new(", method.Input.GoIdent, ")")
 		g.P("if err := stream.RecvMsg(m); err != nil { return err }")
-		g.P("return srv.(", service.GoName, "Server).", method.GoName, "(m, &", streamType, "{stream})")
+		g.P("return srv.(", cfg.serverName(service), ").", method.GoName, "(m, ", streamValue, ")")
 	} else {
-		g.P("return srv.(", service.GoName, "Server).", method.GoName, "(&", streamType, "{stream})")
+		g.P("return srv.(", cfg.serverName(service), ").", method.GoName, "(", streamValue, ")")
 	}
 	g.P("}")
 	g.P()
 
+	if cfg.genericStreams {
+		// Type alias for the generic stream, in place of the auxiliary types below.
+		g.P("// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.")
+		g.P("type ", service.GoName, "_", method.GoName, "Server = ", genericServerStreamType(g, method))
+		g.P()
+		return hname
+	}
+
 	genSend := method.Desc.IsStreamingServer()
 	genSendAndClose := !method.Desc.IsStreamingServer()
 	genRecv := method.Desc.IsStreamingClient()
@@ -519,6 +588,36 @@ func genServerMethod(gen *protogen.Plugin, file *protogen.File, g *protogen.Gene
 	return hname
 }
 
+// genericClientStreamType returns the generic grpc stream type used by the
+// client side of a streaming method, e.g. grpc.ServerStreamingClient[Res].
+func genericClientStreamType(g *protogen.GeneratedFile, method *protogen.Method) string {
+	in := g.QualifiedGoIdent(method.Input.GoIdent)
+	out := g.QualifiedGoIdent(method.Output.GoIdent)
+	switch {
+	case method.Desc.IsStreamingClient() && method.Desc.IsStreamingServer():
+		return g.QualifiedGoIdent(grpcPackage.Ident("BidiStreamingClient")) + "[" + in + ", " + out + "]"
+	case method.Desc.IsStreamingClient():
+		return g.QualifiedGoIdent(grpcPackage.Ident("ClientStreamingClient")) + "[" + in + ", " + out + "]"
+	default:
+		return g.QualifiedGoIdent(grpcPackage.Ident("ServerStreamingClient")) + "[" + out + "]"
+	}
+}
+
+// genericServerStreamType returns the generic grpc stream type used by the
+// server side of a streaming method, e.g. grpc.BidiStreamingServer[Req, Res].
+func genericServerStreamType(g *protogen.GeneratedFile, method *protogen.Method) string {
+	in := g.QualifiedGoIdent(method.Input.GoIdent)
+	out := g.QualifiedGoIdent(method.Output.GoIdent)
+	switch {
+	case method.Desc.IsStreamingClient() && method.Desc.IsStreamingServer():
+		return g.QualifiedGoIdent(grpcPackage.Ident("BidiStreamingServer")) + "[" + in + ", " + out + "]"
+	case method.Desc.IsStreamingClient():
+		return g.QualifiedGoIdent(grpcPackage.Ident("ClientStreamingServer")) + "[" + in + ", " + out + "]"
+	default:
+		return g.QualifiedGoIdent(grpcPackage.Ident("ServerStreamingServer")) + "[" + out + "]"
+	}
+}
+
 func genLeadingComments(g *protogen.GeneratedFile, loc protoreflect.SourceLocation) {
 	for _, s := range loc.LeadingDetachedComments {
 		g.P(protogen.Comments(s))
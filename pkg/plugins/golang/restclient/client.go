@@ -0,0 +1,135 @@
+package restclient
+
+import (
+	"strconv"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/kralicky/grpc-gateway/v2/pkg/descriptor"
+	"google.golang.org/protobuf/compiler/protogen"
+)
+
+// generateFile generates a _rest.pb.go file containing REST clients for the
+// services in the file which have at least one HTTP binding.
+func generateFile(gen *protogen.Plugin, f *protogen.File, file *descriptor.File, cfg config) {
+	bindings := map[string][]*descriptor.Binding{}
+	for _, svc := range file.Services {
+		for _, m := range svc.Methods {
+			bindings[strings.TrimPrefix(m.FQMN(), ".")] = m.Bindings
+		}
+	}
+	var services []*protogen.Service
+	for _, service := range f.Services {
+		for _, method := range service.Methods {
+			if len(bindings[string(method.Desc.FullName())]) > 0 {
+				services = append(services, service)
+				break
+			}
+		}
+	}
+	if len(services) == 0 {
+		return
+	}
+
+	g := gen.NewGeneratedFile(f.GeneratedFilenamePrefix+"_rest.pb.go", f.GoImportPath)
+	g.P("// Code generated by go-rest-client. DO NOT EDIT.")
+	g.P("// source: ", f.Desc.Path())
+	g.P()
+	g.P("package ", f.GoPackageName)
+	g.P()
+	for _, service := range services {
+		genService(g, service, bindings, cfg)
+	}
+}
+
+func genService(g *protogen.GeneratedFile, service *protogen.Service, bindings map[string][]*descriptor.Binding, cfg config) {
+	clientName := service.GoName + cfg.clientSuffix
+	structName := unexport(service.GoName) + "RESTClient"
+
+	g.P("type ", structName, " struct {")
+	g.P("cc *", restPackage.Ident("Client"))
+	g.P("}")
+	g.P()
+	g.P("// New", service.GoName, "RESTClient returns a ", clientName, " which calls the ", service.GoName, " service")
+	g.P("// through its HTTP endpoints, as served by grpc-gateway. Methods with more")
+	g.P("// than one binding use the first. Client and bidirectional streaming")
+	g.P("// methods, and methods without bindings, return codes.Unimplemented.")
+	g.P("func New", service.GoName, "RESTClient(cc *", restPackage.Ident("Client"), ") ", clientName, " {")
+	g.P("return &", structName, "{cc}")
+	g.P("}")
+	g.P()
+
+	for _, method := range service.Methods {
+		var binding *descriptor.Binding
+		if b := bindings[string(method.Desc.FullName())]; len(b) > 0 {
+			binding = b[0]
+		}
+		genMethod(g, structName, method, binding)
+	}
+}
+
+func genMethod(g *protogen.GeneratedFile, structName string, method *protogen.Method, binding *descriptor.Binding) {
+	g.P("func (c *", structName, ") ", signature(g, method), " {")
+	switch {
+	case method.Desc.IsStreamingClient():
+		g.P("return nil, ", statusPackage.Ident("Error"), "(", codesPackage.Ident("Unimplemented"), `, "method `, method.GoName, ` is not supported by the REST client")`)
+	case binding == nil:
+		g.P("return nil, ", statusPackage.Ident("Error"), "(", codesPackage.Ident("Unimplemented"), `, "method `, method.GoName, ` has no HTTP binding")`)
+	case method.Desc.IsStreamingServer():
+		g.P("stream, err := ", restPackage.Ident("NewServerStream"), "[", method.Output.GoIdent, "](ctx, c.cc, ", bindingLiteral(g, binding), ", in, opts...)")
+		g.P("if err != nil {")
+		g.P("return nil, err")
+		g.P("}")
+		g.P("return stream, nil")
+	default:
+		g.P("out := new(", method.Output.GoIdent, ")")
+		g.P("err := c.cc.Invoke(ctx, ", bindingLiteral(g, binding), ", in, out, opts...)")
+		g.P("if err != nil {")
+		g.P("return nil, err")
+		g.P("}")
+		g.P("return out, nil")
+	}
+	g.P("}")
+	g.P()
+}
+
+// signature returns the method signature of the go-grpc client interface.
+func signature(g *protogen.GeneratedFile, method *protogen.Method) string {
+	s := method.GoName + "(ctx " + g.QualifiedGoIdent(contextPackage.Ident("Context"))
+	if !method.Desc.IsStreamingClient() {
+		s += ", in *" + g.QualifiedGoIdent(method.Input.GoIdent)
+	}
+	s += ", opts ..." + g.QualifiedGoIdent(grpcPackage.Ident("CallOption")) + ") ("
+	if !method.Desc.IsStreamingClient() && !method.Desc.IsStreamingServer() {
+		s += "*" + g.QualifiedGoIdent(method.Output.GoIdent)
+	} else {
+		s += method.Parent.GoName + "_" + method.GoName + "Client"
+	}
+	s += ", error)"
+	return s
+}
+
+func bindingLiteral(g *protogen.GeneratedFile, b *descriptor.Binding) string {
+	var sb strings.Builder
+	sb.WriteString("&" + g.QualifiedGoIdent(restPackage.Ident("Binding")) + "{")
+	sb.WriteString("Method: " + strconv.Quote(b.HTTPMethod))
+	sb.WriteString(", Template: " + strconv.Quote(b.PathTmpl.Template))
+	if b.Body != nil {
+		body := b.Body.FieldPath.String()
+		if body == "" {
+			body = "*"
+		}
+		sb.WriteString(", Body: " + strconv.Quote(body))
+	}
+	if b.ResponseBody != nil {
+		sb.WriteString(", ResponseBody: " + strconv.Quote(b.ResponseBody.FieldPath.String()))
+	}
+	sb.WriteString("}")
+	return sb.String()
+}
+
+func unexport(s string) string {
+	r, n := utf8.DecodeRuneInString(s)
+	return string(unicode.ToLower(r)) + s[n:]
+}
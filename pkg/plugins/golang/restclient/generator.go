@@ -0,0 +1,133 @@
+// Package restclient implements the go-rest-client generator, which generates
+// clients that call gRPC services through their google.api.http bindings, as
+// served by grpc-gateway. The generated clients implement the same interface
+// as the go-grpc clients, and use the runtime in the pkg/rest package.
+package restclient
+
+import (
+	"fmt"
+	"strconv"
+
+	"github.com/kralicky/grpc-gateway/v2/pkg/descriptor"
+	"github.com/kralicky/ragu/pkg/util"
+	"github.com/samber/lo"
+	"google.golang.org/protobuf/compiler/protogen"
+)
+
+const (
+	contextPackage = protogen.GoImportPath("context")
+	grpcPackage    = protogen.GoImportPath("google.golang.org/grpc")
+	codesPackage   = protogen.GoImportPath("google.golang.org/grpc/codes")
+	statusPackage  = protogen.GoImportPath("google.golang.org/grpc/status")
+	restPackage    = protogen.GoImportPath("github.com/kralicky/ragu/pkg/rest")
+)
+
+var Generator = generator{}
+
+type Options struct {
+	// Opt is a protoc-style parameter string. In addition to the standard
+	// protogen parameters, "client_suffix", "allow_delete_body", and
+	// "grpc_api_configuration" are accepted. Parameters in Opt take precedence
+	// over the fields below.
+	Opt string
+	// ClientSuffix is the suffix of the client interface generated by the
+	// go-grpc generator. It must match the go-grpc ClientSuffix option.
+	// Defaults to "Client".
+	ClientSuffix string
+	// AllowDeleteBody allows HTTP DELETE methods to have a body.
+	AllowDeleteBody bool
+	// GrpcAPIConfiguration is the path to a gRPC API Configuration file in
+	// YAML format, containing HTTP rules for methods that are not annotated
+	// in the proto sources.
+	GrpcAPIConfiguration string
+}
+
+// NewGenerator returns a go-rest-client generator with the given options.
+func NewGenerator(opts Options) generator {
+	return generator{Options: opts}
+}
+
+type generator struct {
+	Options
+}
+
+func (generator) Name() string {
+	return "go-rest-client"
+}
+
+func (g generator) Parameter() string {
+	return g.Opt
+}
+
+// config holds the settings for a single call to Generate.
+type config struct {
+	clientSuffix         string
+	allowDeleteBody      bool
+	grpcAPIConfiguration string
+}
+
+func (g generator) config(param string) (config, error) {
+	cfg := config{
+		clientSuffix:         lo.Ternary(g.ClientSuffix != "", g.ClientSuffix, "Client"),
+		allowDeleteBody:      g.AllowDeleteBody,
+		grpcAPIConfiguration: g.GrpcAPIConfiguration,
+	}
+	if err := util.ParsePluginParams(param, cfg.set); err != nil {
+		return config{}, err
+	}
+	return cfg, nil
+}
+
+func (c *config) set(name, value string) error {
+	switch name {
+	case "client_suffix":
+		c.clientSuffix = value
+	case "allow_delete_body":
+		if value == "" {
+			c.allowDeleteBody = true
+			return nil
+		}
+		b, err := strconv.ParseBool(value)
+		if err != nil {
+			return fmt.Errorf("go-rest-client: bad value for parameter %q: %w", name, err)
+		}
+		c.allowDeleteBody = b
+	case "grpc_api_configuration":
+		c.grpcAPIConfiguration = value
+	default:
+		return fmt.Errorf("go-rest-client: unknown parameter %q", name)
+	}
+	return nil
+}
+
+func (g generator) Generate(gen *protogen.Plugin) error {
+	cfg, err := g.config(gen.Request.GetParameter())
+	if err != nil {
+		return err
+	}
+
+	// The bindings are parsed by the grpc-gateway registry, so that the
+	// generated clients match the routes served by the gateway.
+	reg := descriptor.NewRegistry()
+	if cfg.grpcAPIConfiguration != "" {
+		if err := reg.LoadGrpcAPIServiceFromYAML(cfg.grpcAPIConfiguration); err != nil {
+			return err
+		}
+	}
+	reg.SetAllowDeleteBody(cfg.allowDeleteBody)
+	if err := reg.LoadFromPlugin(gen); err != nil {
+		return err
+	}
+
+	for _, f := range gen.Files {
+		if !f.Generate {
+			continue
+		}
+		file, err := reg.LookupFile(f.Desc.Path())
+		if err != nil {
+			return err
+		}
+		generateFile(gen, f, file, cfg)
+	}
+	return nil
+}
@@ -0,0 +1,183 @@
+package restclient_test
+
+import (
+	"testing"
+
+	"github.com/kralicky/ragu"
+	"github.com/kralicky/ragu/internal/gentest"
+	"github.com/kralicky/ragu/pkg/plugins/golang"
+	"github.com/kralicky/ragu/pkg/plugins/golang/gateway"
+	"github.com/kralicky/ragu/pkg/plugins/golang/grpc"
+	"github.com/kralicky/ragu/pkg/plugins/golang/restclient"
+)
+
+const streamProtos = "../../../../testdata/stream1/*.proto"
+
+// TestClient calls a live gateway through the REST client, in both stream
+// modes of the go-grpc generator.
+func TestClient(t *testing.T) {
+	for _, mode := range []struct {
+		name     string
+		opts     grpc.Options
+		requires []string
+	}{
+		{"default", grpc.Options{}, nil},
+		{"generic", grpc.Options{GenericStreams: true}, []string{"google.golang.org/grpc@v1.64.0"}},
+	} {
+		mode := mode
+		t.Run(mode.name, func(t *testing.T) {
+			t.Parallel()
+			out, err := ragu.GenerateCode([]ragu.Generator{
+				golang.Generator,
+				grpc.NewGenerator(mode.opts),
+				gateway.Generator,
+				restclient.Generator,
+			}, streamProtos)
+			if err != nil {
+				t.Fatal(err)
+			}
+			m := gentest.NewModule(t, mode.requires...)
+			m.WriteGenerated(out)
+			m.CopyPackage("pkg/rest")
+			m.WriteFile("testdata/stream1/rest_test.go", clientTest)
+			m.Test("./testdata/stream1")
+		})
+	}
+}
+
+// TestClientSuffix checks that the REST client implements the go-grpc client
+// interface when both generators use the same client suffix.
+func TestClientSuffix(t *testing.T) {
+	out, err := ragu.GenerateCode([]ragu.Generator{
+		golang.Generator,
+		grpc.NewGenerator(grpc.Options{ClientSuffix: "GRPCClient"}),
+		restclient.NewGenerator(restclient.Options{Opt: "client_suffix=GRPCClient"}),
+	}, streamProtos)
+	if err != nil {
+		t.Fatal(err)
+	}
+	m := gentest.NewModule(t)
+	m.WriteGenerated(out)
+	m.CopyPackage("pkg/rest")
+	m.WriteFile("testdata/stream1/rest_test.go", `package stream1
+
+import "github.com/kralicky/ragu/pkg/rest"
+
+var _ StreamerGRPCClient = NewStreamerRESTClient(rest.NewClient("http://localhost", nil))
+`)
+	m.Build()
+}
+
+// clientTest serves the gateway in front of a grpc server, and calls it with
+// the REST client through the go-grpc client interface.
+const clientTest = `package stream1
+
+import (
+	"context"
+	"io"
+	"net"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/kralicky/grpc-gateway/v2/runtime"
+	"github.com/kralicky/ragu/pkg/rest"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+)
+
+type server struct {
+	UnimplementedStreamerServer
+}
+
+func (server) Unary(_ context.Context, in *Item) (*Item, error) {
+	if in.Name == "missing" {
+		return nil, status.Error(codes.NotFound, "item not found")
+	}
+	return &Item{Name: in.Name, Count: in.Count + 1}, nil
+}
+
+func (server) ServerStream(in *Item, stream Streamer_ServerStreamServer) error {
+	for i := int32(0); i < in.Count; i++ {
+		if err := stream.Send(&Item{Name: in.Name, Count: i}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func newRESTClient(t *testing.T) StreamerClient {
+	lis := bufconn.Listen(1024 * 1024)
+	srv := grpc.NewServer()
+	RegisterStreamerServer(srv, server{})
+	go srv.Serve(lis)
+	t.Cleanup(srv.Stop)
+
+	conn, err := grpc.DialContext(context.Background(), "bufnet",
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) {
+			return lis.DialContext(ctx)
+		}),
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { conn.Close() })
+
+	mux := runtime.NewServeMux()
+	if err := RegisterStreamerHandlerClient(context.Background(), mux, NewStreamerClient(conn)); err != nil {
+		t.Fatal(err)
+	}
+	gw := httptest.NewServer(mux)
+	t.Cleanup(gw.Close)
+	return NewStreamerRESTClient(rest.NewClient(gw.URL, gw.Client()))
+}
+
+func TestRESTClient(t *testing.T) {
+	ctx := context.Background()
+	client := newRESTClient(t)
+
+	out, err := client.Unary(ctx, &Item{Name: "a", Count: 1})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if out.Name != "a" || out.Count != 2 {
+		t.Fatalf("Unary: unexpected response %v", out)
+	}
+
+	_, err = client.Unary(ctx, &Item{Name: "missing"})
+	if status.Code(err) != codes.NotFound {
+		t.Fatalf("Unary: expected NotFound, got %v", err)
+	}
+
+	stream, err := client.ServerStream(ctx, &Item{Name: "a", Count: 3})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var n int32
+	for ; ; n++ {
+		item, err := stream.Recv()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+		if item.Name != "a" || item.Count != n {
+			t.Fatalf("ServerStream: unexpected message %v", item)
+		}
+	}
+	if n != 3 {
+		t.Fatalf("ServerStream: expected 3 messages, got %d", n)
+	}
+
+	if _, err := client.ClientStream(ctx); status.Code(err) != codes.Unimplemented {
+		t.Fatalf("ClientStream: expected Unimplemented, got %v", err)
+	}
+	if _, err := client.BidiStream(ctx); status.Code(err) != codes.Unimplemented {
+		t.Fatalf("BidiStream: expected Unimplemented, got %v", err)
+	}
+}
+`
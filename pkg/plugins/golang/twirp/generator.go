@@ -0,0 +1,108 @@
+// Package twirp implements the go-twirp generator, which generates Twirp
+// servers and clients equivalent to the output of protoc-gen-twirp.
+package twirp
+
+import (
+	"fmt"
+
+	"github.com/kralicky/ragu/pkg/util"
+	"google.golang.org/protobuf/compiler/protogen"
+)
+
+// Version of protoc-gen-twirp that the generated code is derived from.
+const version = "v8.1.3"
+
+var Generator = generator{}
+
+type Options struct {
+	// Opt is a protoc-style parameter string. Only the standard protogen
+	// parameters are accepted.
+	Opt string
+	// DiagnosticHook is called for each streaming method, which Twirp does
+	// not support. Streaming methods are omitted from the generated service,
+	// and noted in its doc comment. If nil, they are omitted silently.
+	DiagnosticHook func(error)
+}
+
+// NewGenerator returns a go-twirp generator with the given options.
+func NewGenerator(opts Options) generator {
+	return generator{Options: opts}
+}
+
+type generator struct {
+	Options
+}
+
+func (generator) Name() string {
+	return "go-twirp"
+}
+
+func (g generator) Parameter() string {
+	return g.Opt
+}
+
+func (g generator) Generate(gen *protogen.Plugin) error {
+	if err := util.ParsePluginParams(gen.Request.GetParameter(), func(name, _ string) error {
+		return fmt.Errorf("go-twirp: unknown parameter %q", name)
+	}); err != nil {
+		return err
+	}
+
+	// Helper functions are generated once per Go package, in the first file
+	// of the package containing services.
+	packages := map[protogen.GoImportPath]*packageState{}
+	for _, f := range gen.Files {
+		if !f.Generate || len(f.Services) == 0 {
+			continue
+		}
+		if g.DiagnosticHook != nil {
+			for _, service := range f.Services {
+				for _, method := range streamingMethods(service) {
+					g.DiagnosticHook(fmt.Errorf("go-twirp: %s: streaming methods are not supported", method.Desc.FullName()))
+				}
+			}
+		}
+		pkg, ok := packages[f.GoImportPath]
+		if !ok {
+			pkg = &packageState{}
+			packages[f.GoImportPath] = pkg
+		}
+		if err := generateFile(gen, f, pkg); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// packageState tracks the files generated in a Go package.
+type packageState struct {
+	// number of files generated so far, used to name the file descriptor
+	// variables
+	files int
+}
+
+// unaryMethods returns the methods of a service that are generated.
+func unaryMethods(service *protogen.Service) []*protogen.Method {
+	var methods []*protogen.Method
+	for _, method := range service.Methods {
+		if !isStreaming(method) {
+			methods = append(methods, method)
+		}
+	}
+	return methods
+}
+
+// streamingMethods returns the methods of a service that are omitted.
+func streamingMethods(service *protogen.Service) []*protogen.Method {
+	var methods []*protogen.Method
+	for _, method := range service.Methods {
+		if isStreaming(method) {
+			methods = append(methods, method)
+		}
+	}
+	return methods
+}
+
+func isStreaming(method *protogen.Method) bool {
+	return method.Desc.IsStreamingClient() || method.Desc.IsStreamingServer()
+}
@@ -0,0 +1,602 @@
+package twirp
+
+import (
+	"bytes"
+	"compress/gzip"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"google.golang.org/protobuf/compiler/protogen"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/descriptorpb"
+)
+
+// imports maps the package names used in templates to import paths.
+var imports = map[string]protogen.GoImportPath{
+	"bytes":      "bytes",
+	"context":    "context",
+	"errors":     "errors",
+	"fmt":        "fmt",
+	"http":       "net/http",
+	"io":         "io",
+	"json":       "encoding/json",
+	"path":       "path",
+	"strconv":    "strconv",
+	"strings":    "strings",
+	"url":        "net/url",
+	"proto":      "google.golang.org/protobuf/proto",
+	"protojson":  "google.golang.org/protobuf/encoding/protojson",
+	"twirp":      "github.com/twitchtv/twirp",
+	"ctxsetters": "github.com/twitchtv/twirp/ctxsetters",
+}
+
+var qualifiedIdentRegex = regexp.MustCompile(`\$(\w+)\.(\w+)`)
+
+// emit writes a code template to g. Imported identifiers are written as
+// $pkg.Name, where pkg is a key in imports, and template variables are
+// written as {{Name}}.
+func emit(g *protogen.GeneratedFile, tmpl string, vars map[string]string) {
+	oldnew := make([]string, 0, 2*len(vars))
+	for k, v := range vars {
+		oldnew = append(oldnew, "{{"+k+"}}", v)
+	}
+	src := strings.NewReplacer(oldnew...).Replace(tmpl)
+	src = qualifiedIdentRegex.ReplaceAllStringFunc(src, func(s string) string {
+		m := qualifiedIdentRegex.FindStringSubmatch(s)
+		importPath, ok := imports[m[1]]
+		if !ok {
+			panic("twirp: unknown package in template: " + m[1])
+		}
+		return g.QualifiedGoIdent(importPath.Ident(m[2]))
+	})
+	g.P(strings.TrimPrefix(src, "\n"))
+}
+
+// generateFile generates a .twirp.go file containing the Twirp servers and
+// clients for the services in the file.
+func generateFile(gen *protogen.Plugin, file *protogen.File, pkg *packageState) error {
+	g := gen.NewGeneratedFile(file.GeneratedFilenamePrefix+".twirp.go", file.GoImportPath)
+	g.P("// Code generated by protoc-gen-twirp ", version, ", DO NOT EDIT.")
+	g.P("// source: ", file.Desc.Path())
+	g.P()
+	g.P("package ", file.GoPackageName)
+	g.P()
+	emit(g, `
+// Version compatibility assertion.
+// If the constant is not defined in the package, that likely means
+// the package needs to be updated to work with this generated code.
+// See https://twitchtv.github.io/twirp/docs/version_matrix.html
+const _ = $twirp.TwirpPackageMinVersion_8_1_0
+`, nil)
+
+	descriptorVar := "twirpFileDescriptor" + strconv.Itoa(pkg.files)
+	for i, service := range file.Services {
+		genService(g, file, service, i, descriptorVar)
+	}
+	if pkg.files == 0 {
+		genUtils(g)
+	}
+	if err := genFileDescriptor(g, file, descriptorVar); err != nil {
+		return err
+	}
+	pkg.files++
+	return nil
+}
+
+func genService(g *protogen.GeneratedFile, file *protogen.File, service *protogen.Service, index int, descriptorVar string) {
+	pkgName := string(file.Desc.Package())
+	vars := map[string]string{
+		"Service":       service.GoName,
+		"service":       unexport(service.GoName),
+		"PkgName":       strconv.Quote(pkgName),
+		"ServiceName":   strconv.Quote(service.GoName),
+		"LiteralName":   strconv.Quote(string(service.Desc.Name())),
+		"RouteCheck":    routeCheck(pkgName, service),
+		"PathPrefix":    strconv.Quote("/twirp/" + fullServiceName(pkgName, service.GoName) + "/"),
+		"NumMethods":    strconv.Itoa(len(unaryMethods(service))),
+		"Index":         strconv.Itoa(index),
+		"DescriptorVar": descriptorVar,
+	}
+
+	genBanner(g, service.GoName+" Interface")
+	g.Annotate(service.GoName, service.Location)
+	serviceLeading := service.Comments.Leading
+	if omitted := streamingMethods(service); len(omitted) > 0 {
+		if serviceLeading != "" {
+			serviceLeading += "\n"
+		}
+		serviceLeading += " The following streaming methods are not supported by Twirp, and are omitted:\n"
+		for _, method := range omitted {
+			serviceLeading += protogen.Comments("   - " + method.GoName + "\n")
+		}
+	}
+	g.P(serviceLeading, "type ", service.GoName, " interface {")
+	for i, method := range unaryMethods(service) {
+		if i > 0 {
+			g.P()
+		}
+		g.Annotate(service.GoName+"."+method.GoName, method.Location)
+		leading := method.Comments.Leading
+		if isDeprecated(method) {
+			if leading != "" {
+				leading += "\n"
+			}
+			leading += " Deprecated: do not use.\n"
+		}
+		g.P(leading, method.GoName, "(", g.QualifiedGoIdent(imports["context"].Ident("Context")), ", *", method.Input.GoIdent, ") (*", method.Output.GoIdent, ", error)")
+	}
+	g.P("}")
+	g.P()
+
+	genClient(g, service, vars, "Protobuf", "doProtobufRequest")
+	genClient(g, service, vars, "JSON", "doJSONRequest")
+	genServer(g, service, vars)
+}
+
+func genClient(g *protogen.GeneratedFile, service *protogen.Service, vars map[string]string, kind, do string) {
+	vars = withVars(vars, map[string]string{"Kind": kind, "Do": do})
+	genBanner(g, service.GoName+" "+kind+" Client")
+
+	var urls, literalURLs strings.Builder
+	for _, method := range unaryMethods(service) {
+		fmt.Fprintf(&urls, "serviceURL + %q,\n", method.GoName)
+		fmt.Fprintf(&literalURLs, "literalServiceURL + %q,\n", method.Desc.Name())
+	}
+	emit(g, `
+type {{service}}{{Kind}}Client struct {
+	client      HTTPClient
+	urls        [{{NumMethods}}]string
+	interceptor $twirp.Interceptor
+	opts        $twirp.ClientOptions
+}
+
+// New{{Service}}{{Kind}}Client creates a {{Kind}} client that implements the {{Service}} interface.
+// It communicates using {{Kind}} and can be configured with a custom HTTPClient.
+func New{{Service}}{{Kind}}Client(baseURL string, client HTTPClient, opts ...$twirp.ClientOption) {{Service}} {
+	if c, ok := client.(*$http.Client); ok {
+		client = withoutRedirects(c)
+	}
+
+	clientOpts := $twirp.ClientOptions{}
+	for _, o := range opts {
+		o(&clientOpts)
+	}
+
+	// Using ReadOpt allows backwards and forwards compatibility with new options in the future
+	literalURLs := false
+	_ = clientOpts.ReadOpt("literalURLs", &literalURLs)
+	var pathPrefix string
+	if ok := clientOpts.ReadOpt("pathPrefix", &pathPrefix); !ok {
+		pathPrefix = "/twirp" // default prefix
+	}
+
+	// Build method URLs: <baseURL>[<prefix>]/<package>.<Service>/<Method>
+	serviceURL := sanitizeBaseURL(baseURL)
+	literalServiceURL := serviceURL + baseServicePath(pathPrefix, {{PkgName}}, {{LiteralName}})
+	serviceURL += baseServicePath(pathPrefix, {{PkgName}}, {{ServiceName}})
+	urls := [{{NumMethods}}]string{
+`+urls.String()+`	}
+	if literalURLs {
+		urls = [{{NumMethods}}]string{
+`+literalURLs.String()+`		}
+	}
+
+	return &{{service}}{{Kind}}Client{
+		client:      client,
+		urls:        urls,
+		interceptor: $twirp.ChainInterceptors(clientOpts.Interceptors...),
+		opts:        clientOpts,
+	}
+}
+`, vars)
+
+	for i, method := range unaryMethods(service) {
+		mvars := withVars(vars, methodVars(g, method))
+		mvars["Index"] = strconv.Itoa(i)
+		emit(g, `
+func (c *{{service}}{{Kind}}Client) {{Method}}(ctx $context.Context, in *{{Input}}) (*{{Output}}, error) {
+	ctx = $ctxsetters.WithPackageName(ctx, {{PkgName}})
+	ctx = $ctxsetters.WithServiceName(ctx, {{ServiceName}})
+	ctx = $ctxsetters.WithMethodName(ctx, {{MethodName}})
+	caller := c.call{{Method}}
+	if c.interceptor != nil {
+		caller = func(ctx $context.Context, req *{{Input}}) (*{{Output}}, error) {
+			resp, err := c.interceptor(
+				func(ctx $context.Context, req interface{}) (interface{}, error) {
+					typedReq, ok := req.(*{{Input}})
+					if !ok {
+						return nil, $twirp.InternalError("failed type assertion req.(*{{Input}}) when calling interceptor")
+					}
+					return c.call{{Method}}(ctx, typedReq)
+				},
+			)(ctx, req)
+			if resp != nil {
+				typedResp, ok := resp.(*{{Output}})
+				if !ok {
+					return nil, $twirp.InternalError("failed type assertion resp.(*{{Output}}) when calling interceptor")
+				}
+				return typedResp, err
+			}
+			return nil, err
+		}
+	}
+	return caller(ctx, in)
+}
+
+func (c *{{service}}{{Kind}}Client) call{{Method}}(ctx $context.Context, in *{{Input}}) (*{{Output}}, error) {
+	out := new({{Output}})
+	ctx, err := {{Do}}(ctx, c.client, c.opts.Hooks, c.urls[{{Index}}], in, out)
+	if err != nil {
+		twerr, ok := err.($twirp.Error)
+		if !ok {
+			twerr = $twirp.InternalErrorWith(err)
+		}
+		callClientError(ctx, c.opts.Hooks, twerr)
+		return nil, err
+	}
+
+	callClientResponseReceived(ctx, c.opts.Hooks)
+
+	return out, nil
+}
+`, mvars)
+	}
+}
+
+func genServer(g *protogen.GeneratedFile, service *protogen.Service, vars map[string]string) {
+	genBanner(g, service.GoName+" Server Handler")
+
+	var cases strings.Builder
+	for _, method := range unaryMethods(service) {
+		names := []string{strconv.Quote(method.GoName)}
+		if string(method.Desc.Name()) != method.GoName {
+			names = append(names, strconv.Quote(string(method.Desc.Name())))
+		}
+		fmt.Fprintf(&cases, "case %s:\ns.serve%s(ctx, resp, req)\nreturn\n", strings.Join(names, ", "), method.GoName)
+	}
+	vars = withVars(vars, map[string]string{"Cases": cases.String()})
+
+	emit(g, `
+type {{service}}Server struct {
+	{{Service}}
+	interceptor      $twirp.Interceptor
+	hooks            *$twirp.ServerHooks
+	pathPrefix       string // prefix for routing
+	jsonSkipDefaults bool   // do not include unpopulated fields (default values) in the response
+	jsonCamelCase    bool   // JSON fields are serialized as lowerCamelCase rather than keeping the original proto names
+}
+
+// New{{Service}}Server builds a TwirpServer that can be used as an http.Handler to handle
+// HTTP requests that are routed to the right method in the provided svc implementation.
+// The opts are twirp.ServerOption modifiers, for example twirp.WithServerHooks(hooks).
+func New{{Service}}Server(svc {{Service}}, opts ...interface{}) TwirpServer {
+	serverOpts := newServerOpts(opts)
+
+	// Using ReadOpt allows backwards and forwards compatibility with new options in the future
+	jsonSkipDefaults := false
+	_ = serverOpts.ReadOpt("jsonSkipDefaults", &jsonSkipDefaults)
+	jsonCamelCase := false
+	_ = serverOpts.ReadOpt("jsonCamelCase", &jsonCamelCase)
+	var pathPrefix string
+	if ok := serverOpts.ReadOpt("pathPrefix", &pathPrefix); !ok {
+		pathPrefix = "/twirp" // default prefix
+	}
+
+	return &{{service}}Server{
+		{{Service}}:      svc,
+		hooks:            serverOpts.Hooks,
+		interceptor:      $twirp.ChainInterceptors(serverOpts.Interceptors...),
+		pathPrefix:       pathPrefix,
+		jsonSkipDefaults: jsonSkipDefaults,
+		jsonCamelCase:    jsonCamelCase,
+	}
+}
+
+// writeError writes an HTTP response with a valid Twirp error format, and triggers hooks.
+// If err is not a twirp.Error, it will get wrapped with twirp.InternalErrorWith(err)
+func (s *{{service}}Server) writeError(ctx $context.Context, resp $http.ResponseWriter, err error) {
+	writeError(ctx, resp, err, s.hooks)
+}
+
+// handleRequestBodyError is used to handle error when the twirp server cannot read request
+func (s *{{service}}Server) handleRequestBodyError(ctx $context.Context, resp $http.ResponseWriter, msg string, err error) {
+	if $context.Canceled == ctx.Err() {
+		s.writeError(ctx, resp, $twirp.NewError($twirp.Canceled, "failed to read request: context canceled"))
+		return
+	}
+	if $context.DeadlineExceeded == ctx.Err() {
+		s.writeError(ctx, resp, $twirp.NewError($twirp.DeadlineExceeded, "failed to read request: deadline exceeded"))
+		return
+	}
+	s.writeError(ctx, resp, $twirp.WrapError(malformedRequestError(msg), err))
+}
+
+// {{Service}}PathPrefix is a convenience constant that may identify URL paths.
+// Should be used with caution, it only matches routes generated by Twirp Go clients,
+// with the default "/twirp" prefix and default CamelCase service and method names.
+// More info: https://twitchtv.github.io/twirp/docs/routing.html
+const {{Service}}PathPrefix = {{PathPrefix}}
+
+func (s *{{service}}Server) ServeHTTP(resp $http.ResponseWriter, req *$http.Request) {
+	ctx := req.Context()
+	ctx = $ctxsetters.WithPackageName(ctx, {{PkgName}})
+	ctx = $ctxsetters.WithServiceName(ctx, {{ServiceName}})
+	ctx = $ctxsetters.WithResponseWriter(ctx, resp)
+
+	var err error
+	ctx, err = callRequestReceived(ctx, s.hooks)
+	if err != nil {
+		s.writeError(ctx, resp, err)
+		return
+	}
+
+	if req.Method != "POST" {
+		msg := $fmt.Sprintf("unsupported method %q (only POST is allowed)", req.Method)
+		s.writeError(ctx, resp, badRouteError(msg, req.Method, req.URL.Path))
+		return
+	}
+
+	// Verify path format: [<prefix>]/<package>.<Service>/<Method>
+	prefix, pkgService, method := parseTwirpPath(req.URL.Path)
+	if {{RouteCheck}} {
+		msg := $fmt.Sprintf("no handler for path %q", req.URL.Path)
+		s.writeError(ctx, resp, badRouteError(msg, req.Method, req.URL.Path))
+		return
+	}
+	if prefix != s.pathPrefix {
+		msg := $fmt.Sprintf("invalid path prefix %q, expected %q, on path %q", prefix, s.pathPrefix, req.URL.Path)
+		s.writeError(ctx, resp, badRouteError(msg, req.Method, req.URL.Path))
+		return
+	}
+
+	switch method {
+{{Cases}}	default:
+		msg := $fmt.Sprintf("no handler for path %q", req.URL.Path)
+		s.writeError(ctx, resp, badRouteError(msg, req.Method, req.URL.Path))
+		return
+	}
+}
+`, vars)
+
+	for _, method := range unaryMethods(service) {
+		genServerMethod(g, withVars(vars, methodVars(g, method)))
+	}
+
+	emit(g, `
+func (s *{{service}}Server) ServiceDescriptor() ([]byte, int) {
+	return {{DescriptorVar}}, {{Index}}
+}
+
+func (s *{{service}}Server) ProtocGenTwirpVersion() string {
+	return "`+version+`"
+}
+
+// PathPrefix returns the base service path, in the form: "/<prefix>/<package>.<Service>/"
+// that is everything in a Twirp route except for the <Method>. This can be used for routing,
+// for example to identify the requests that are targeted to this service in a mux.
+func (s *{{service}}Server) PathPrefix() string {
+	return baseServicePath(s.pathPrefix, {{PkgName}}, {{ServiceName}})
+}
+`, vars)
+}
+
+func genServerMethod(g *protogen.GeneratedFile, vars map[string]string) {
+	const interceptor = `
+	handler := s.{{Service}}.{{Method}}
+	if s.interceptor != nil {
+		handler = func(ctx $context.Context, req *{{Input}}) (*{{Output}}, error) {
+			resp, err := s.interceptor(
+				func(ctx $context.Context, req interface{}) (interface{}, error) {
+					typedReq, ok := req.(*{{Input}})
+					if !ok {
+						return nil, $twirp.InternalError("failed type assertion req.(*{{Input}}) when calling interceptor")
+					}
+					return s.{{Service}}.{{Method}}(ctx, typedReq)
+				},
+			)(ctx, req)
+			if resp != nil {
+				typedResp, ok := resp.(*{{Output}})
+				if !ok {
+					return nil, $twirp.InternalError("failed type assertion resp.(*{{Output}}) when calling interceptor")
+				}
+				return typedResp, err
+			}
+			return nil, err
+		}
+	}
+
+	// Call service method
+	var respContent *{{Output}}
+	func() {
+		defer ensurePanicResponses(ctx, resp, s.hooks)
+		respContent, err = handler(ctx, reqContent)
+	}()
+
+	if err != nil {
+		s.writeError(ctx, resp, err)
+		return
+	}
+	if respContent == nil {
+		s.writeError(ctx, resp, $twirp.InternalError("received a nil *{{Output}} and nil error while calling {{Method}}. nil responses are not supported"))
+		return
+	}
+
+	ctx = callResponsePrepared(ctx, s.hooks)
+`
+	const writeResponse = `
+	ctx = $ctxsetters.WithStatusCode(ctx, $http.StatusOK)
+	resp.Header().Set("Content-Type", "application/{{ContentType}}")
+	resp.Header().Set("Content-Length", $strconv.Itoa(len(respBytes)))
+	resp.WriteHeader($http.StatusOK)
+
+	if n, err := resp.Write(respBytes); err != nil {
+		msg := $fmt.Sprintf("failed to write response, %d of %d bytes written: %s", n, len(respBytes), err.Error())
+		twerr := $twirp.NewError($twirp.Unknown, msg)
+		ctx = callError(ctx, s.hooks, twerr)
+	}
+	callResponseSent(ctx, s.hooks)
+}
+`
+	emit(g, `
+func (s *{{service}}Server) serve{{Method}}(ctx $context.Context, resp $http.ResponseWriter, req *$http.Request) {
+	header := req.Header.Get("Content-Type")
+	i := $strings.Index(header, ";")
+	if i == -1 {
+		i = len(header)
+	}
+	switch $strings.TrimSpace($strings.ToLower(header[:i])) {
+	case "application/json":
+		s.serve{{Method}}JSON(ctx, resp, req)
+	case "application/protobuf":
+		s.serve{{Method}}Protobuf(ctx, resp, req)
+	default:
+		msg := $fmt.Sprintf("unexpected Content-Type: %q", req.Header.Get("Content-Type"))
+		twerr := badRouteError(msg, req.Method, req.URL.Path)
+		s.writeError(ctx, resp, twerr)
+	}
+}
+
+func (s *{{service}}Server) serve{{Method}}JSON(ctx $context.Context, resp $http.ResponseWriter, req *$http.Request) {
+	var err error
+	ctx = $ctxsetters.WithMethodName(ctx, {{MethodName}})
+	ctx, err = callRequestRouted(ctx, s.hooks)
+	if err != nil {
+		s.writeError(ctx, resp, err)
+		return
+	}
+
+	d := $json.NewDecoder(req.Body)
+	rawReqBody := $json.RawMessage{}
+	if err := d.Decode(&rawReqBody); err != nil {
+		s.handleRequestBodyError(ctx, resp, "the json request could not be decoded", err)
+		return
+	}
+	reqContent := new({{Input}})
+	unmarshaler := $protojson.UnmarshalOptions{DiscardUnknown: true}
+	if err = unmarshaler.Unmarshal(rawReqBody, reqContent); err != nil {
+		s.handleRequestBodyError(ctx, resp, "the json request could not be decoded", err)
+		return
+	}
+`+interceptor+`
+	marshaler := &$protojson.MarshalOptions{UseProtoNames: !s.jsonCamelCase, EmitUnpopulated: !s.jsonSkipDefaults}
+	respBytes, err := marshaler.Marshal(respContent)
+	if err != nil {
+		s.writeError(ctx, resp, wrapInternal(err, "failed to marshal json response"))
+		return
+	}
+`+strings.ReplaceAll(writeResponse, "{{ContentType}}", "json")+`
+func (s *{{service}}Server) serve{{Method}}Protobuf(ctx $context.Context, resp $http.ResponseWriter, req *$http.Request) {
+	var err error
+	ctx = $ctxsetters.WithMethodName(ctx, {{MethodName}})
+	ctx, err = callRequestRouted(ctx, s.hooks)
+	if err != nil {
+		s.writeError(ctx, resp, err)
+		return
+	}
+
+	buf, err := $io.ReadAll(req.Body)
+	if err != nil {
+		s.handleRequestBodyError(ctx, resp, "failed to read request body", err)
+		return
+	}
+	reqContent := new({{Input}})
+	if err = $proto.Unmarshal(buf, reqContent); err != nil {
+		s.writeError(ctx, resp, malformedRequestError("the protobuf request could not be decoded"))
+		return
+	}
+`+interceptor+`
+	respBytes, err := $proto.Marshal(respContent)
+	if err != nil {
+		s.writeError(ctx, resp, wrapInternal(err, "failed to marshal proto response"))
+		return
+	}
+`+strings.ReplaceAll(writeResponse, "{{ContentType}}", "protobuf"), vars)
+}
+
+// genFileDescriptor writes the gzipped FileDescriptorProto of the file,
+// without source code info, which is returned by the ServiceDescriptor method
+// of the generated servers.
+func genFileDescriptor(g *protogen.GeneratedFile, file *protogen.File, descriptorVar string) error {
+	fdp := proto.Clone(file.Proto).(*descriptorpb.FileDescriptorProto)
+	fdp.SourceCodeInfo = nil
+	b, err := proto.MarshalOptions{Deterministic: true}.Marshal(fdp)
+	if err != nil {
+		return fmt.Errorf("go-twirp: failed to marshal file descriptor: %w", err)
+	}
+	var buf bytes.Buffer
+	w, _ := gzip.NewWriterLevel(&buf, gzip.BestCompression)
+	w.Write(b)
+	w.Close()
+	b = buf.Bytes()
+
+	g.P("var ", descriptorVar, " = []byte{")
+	g.P("// ", len(b), " bytes of a gzipped FileDescriptorProto")
+	for len(b) > 0 {
+		n := 16
+		if len(b) < n {
+			n = len(b)
+		}
+		s := ""
+		for _, c := range b[:n] {
+			s += fmt.Sprintf("0x%02x, ", c)
+		}
+		g.P(strings.TrimSuffix(s, " "))
+		b = b[n:]
+	}
+	g.P("}")
+	g.P()
+	return nil
+}
+
+func methodVars(g *protogen.GeneratedFile, method *protogen.Method) map[string]string {
+	return map[string]string{
+		"Method":     method.GoName,
+		"MethodName": strconv.Quote(method.GoName),
+		"Input":      g.QualifiedGoIdent(method.Input.GoIdent),
+		"Output":     g.QualifiedGoIdent(method.Output.GoIdent),
+	}
+}
+
+func withVars(vars map[string]string, extra map[string]string) map[string]string {
+	merged := make(map[string]string, len(vars)+len(extra))
+	for k, v := range vars {
+		merged[k] = v
+	}
+	for k, v := range extra {
+		merged[k] = v
+	}
+	return merged
+}
+
+func genBanner(g *protogen.GeneratedFile, title string) {
+	line := strings.Repeat("=", len(title))
+	g.P("// ", line)
+	g.P("// ", title)
+	g.P("// ", line)
+	g.P()
+}
+
+// routeCheck returns a condition which is true if pkgService does not name
+// the service, either in CamelCase or as written in the proto file.
+func routeCheck(pkg string, service *protogen.Service) string {
+	check := "pkgService != " + strconv.Quote(fullServiceName(pkg, service.GoName))
+	if literal := string(service.Desc.Name()); literal != service.GoName {
+		check += " && pkgService != " + strconv.Quote(fullServiceName(pkg, literal))
+	}
+	return check
+}
+
+func fullServiceName(pkg, service string) string {
+	if pkg == "" {
+		return service
+	}
+	return pkg + "." + service
+}
+
+func isDeprecated(method *protogen.Method) bool {
+	return method.Desc.Options().(*descriptorpb.MethodOptions).GetDeprecated()
+}
+
+func unexport(s string) string { return strings.ToLower(s[:1]) + s[1:] }
@@ -0,0 +1,78 @@
+package twirp_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/kralicky/ragu"
+	"github.com/kralicky/ragu/internal/gentest"
+	"github.com/kralicky/ragu/pkg/plugins/golang"
+	"github.com/kralicky/ragu/pkg/plugins/golang/twirp"
+)
+
+func TestStreamingMethodsOmitted(t *testing.T) {
+	var diagnostics []string
+	out, err := ragu.GenerateCode([]ragu.Generator{
+		golang.Generator,
+		twirp.NewGenerator(twirp.Options{
+			DiagnosticHook: func(err error) {
+				diagnostics = append(diagnostics, err.Error())
+			},
+		}),
+	}, "../../../../testdata/stream1/*.proto")
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := []string{
+		"go-twirp: stream1.Streamer.ServerStream: streaming methods are not supported",
+		"go-twirp: stream1.Streamer.ClientStream: streaming methods are not supported",
+		"go-twirp: stream1.Streamer.BidiStream: streaming methods are not supported",
+	}
+	if strings.Join(diagnostics, "\n") != strings.Join(expected, "\n") {
+		t.Fatalf("unexpected diagnostics:\n%s", strings.Join(diagnostics, "\n"))
+	}
+
+	m := gentest.NewModule(t, "github.com/twitchtv/twirp@v8.1.3+incompatible")
+	m.WriteGenerated(out)
+	m.WriteFile("testdata/stream1/twirp_test.go", `package stream1
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+type server struct{}
+
+func (server) Unary(_ context.Context, in *Item) (*Item, error) {
+	return &Item{Name: in.Name, Count: in.Count + 1}, nil
+}
+
+// the generated interface only contains the unary method
+var _ Streamer = server{}
+
+func TestUnary(t *testing.T) {
+	handler := NewStreamerServer(server{})
+	if handler.PathPrefix() != StreamerPathPrefix || StreamerPathPrefix != "/twirp/stream1.Streamer/" {
+		t.Fatalf("unexpected path prefix %q", handler.PathPrefix())
+	}
+	srv := httptest.NewServer(handler)
+	defer srv.Close()
+
+	for _, client := range []Streamer{
+		NewStreamerProtobufClient(srv.URL, http.DefaultClient),
+		NewStreamerJSONClient(srv.URL, http.DefaultClient),
+	} {
+		out, err := client.Unary(context.Background(), &Item{Name: "a", Count: 1})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if out.Name != "a" || out.Count != 2 {
+			t.Fatalf("unexpected response: %v", out)
+		}
+	}
+}
+`)
+	m.Test("./testdata/stream1")
+}
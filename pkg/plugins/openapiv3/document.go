@@ -0,0 +1,172 @@
+package openapiv3
+
+import (
+	"bytes"
+	"encoding/json"
+
+	"gopkg.in/yaml.v3"
+)
+
+// The types below model the subset of the OpenAPI 3.1 specification
+// (https://spec.openapis.org/oas/v3.1.0) used by the generator.
+
+type document struct {
+	OpenAPI    string               `json:"openapi" yaml:"openapi"`
+	Info       info                 `json:"info" yaml:"info"`
+	Tags       []tag                `json:"tags,omitempty" yaml:"tags,omitempty"`
+	Paths      map[string]*pathItem `json:"paths" yaml:"paths"`
+	Components components           `json:"components" yaml:"components"`
+}
+
+type info struct {
+	Title   string `json:"title" yaml:"title"`
+	Version string `json:"version" yaml:"version"`
+}
+
+type tag struct {
+	Name        string `json:"name" yaml:"name"`
+	Description string `json:"description,omitempty" yaml:"description,omitempty"`
+}
+
+type pathItem struct {
+	Get     *operation `json:"get,omitempty" yaml:"get,omitempty"`
+	Put     *operation `json:"put,omitempty" yaml:"put,omitempty"`
+	Post    *operation `json:"post,omitempty" yaml:"post,omitempty"`
+	Delete  *operation `json:"delete,omitempty" yaml:"delete,omitempty"`
+	Options *operation `json:"options,omitempty" yaml:"options,omitempty"`
+	Head    *operation `json:"head,omitempty" yaml:"head,omitempty"`
+	Patch   *operation `json:"patch,omitempty" yaml:"patch,omitempty"`
+	Trace   *operation `json:"trace,omitempty" yaml:"trace,omitempty"`
+}
+
+// slot returns the operation field for the given HTTP method, or nil if the
+// method is not supported.
+func (p *pathItem) slot(method string) **operation {
+	switch method {
+	case "GET":
+		return &p.Get
+	case "PUT":
+		return &p.Put
+	case "POST":
+		return &p.Post
+	case "DELETE":
+		return &p.Delete
+	case "OPTIONS":
+		return &p.Options
+	case "HEAD":
+		return &p.Head
+	case "PATCH":
+		return &p.Patch
+	case "TRACE":
+		return &p.Trace
+	}
+	return nil
+}
+
+type operation struct {
+	Tags        []string             `json:"tags,omitempty" yaml:"tags,omitempty"`
+	Summary     string               `json:"summary,omitempty" yaml:"summary,omitempty"`
+	Description string               `json:"description,omitempty" yaml:"description,omitempty"`
+	OperationID string               `json:"operationId" yaml:"operationId"`
+	Parameters  []*parameter         `json:"parameters,omitempty" yaml:"parameters,omitempty"`
+	RequestBody *requestBody         `json:"requestBody,omitempty" yaml:"requestBody,omitempty"`
+	Responses   map[string]*response `json:"responses" yaml:"responses"`
+	Deprecated  bool                 `json:"deprecated,omitempty" yaml:"deprecated,omitempty"`
+}
+
+type parameter struct {
+	Name        string  `json:"name" yaml:"name"`
+	In          string  `json:"in" yaml:"in"`
+	Description string  `json:"description,omitempty" yaml:"description,omitempty"`
+	Required    bool    `json:"required,omitempty" yaml:"required,omitempty"`
+	Deprecated  bool    `json:"deprecated,omitempty" yaml:"deprecated,omitempty"`
+	Schema      *schema `json:"schema" yaml:"schema"`
+}
+
+type requestBody struct {
+	Content  map[string]mediaType `json:"content" yaml:"content"`
+	Required bool                 `json:"required,omitempty" yaml:"required,omitempty"`
+}
+
+type response struct {
+	Description string               `json:"description" yaml:"description"`
+	Content     map[string]mediaType `json:"content,omitempty" yaml:"content,omitempty"`
+}
+
+type mediaType struct {
+	Schema *schema `json:"schema" yaml:"schema"`
+}
+
+type components struct {
+	Schemas map[string]*schema `json:"schemas,omitempty" yaml:"schemas,omitempty"`
+}
+
+type schema struct {
+	Ref                  string     `json:"$ref,omitempty" yaml:"$ref,omitempty"`
+	Title                string     `json:"title,omitempty" yaml:"title,omitempty"`
+	Description          string     `json:"description,omitempty" yaml:"description,omitempty"`
+	Type                 any        `json:"type,omitempty" yaml:"type,omitempty"`
+	Format               string     `json:"format,omitempty" yaml:"format,omitempty"`
+	Enum                 []string   `json:"enum,omitempty" yaml:"enum,omitempty"`
+	Items                *schema    `json:"items,omitempty" yaml:"items,omitempty"`
+	Properties           properties `json:"properties,omitempty" yaml:"properties,omitempty"`
+	AdditionalProperties *schema    `json:"additionalProperties,omitempty" yaml:"additionalProperties,omitempty"`
+	Required             []string   `json:"required,omitempty" yaml:"required,omitempty"`
+	ReadOnly             bool       `json:"readOnly,omitempty" yaml:"readOnly,omitempty"`
+	WriteOnly            bool       `json:"writeOnly,omitempty" yaml:"writeOnly,omitempty"`
+	Deprecated           bool       `json:"deprecated,omitempty" yaml:"deprecated,omitempty"`
+}
+
+// properties is an ordered set of named schemas. Properties are encoded in
+// the order the fields are declared in, rather than sorted by name.
+type properties []property
+
+type property struct {
+	name   string
+	schema *schema
+}
+
+func (p properties) MarshalJSON() ([]byte, error) {
+	var buf bytes.Buffer
+	buf.WriteByte('{')
+	for i, prop := range p {
+		if i > 0 {
+			buf.WriteByte(',')
+		}
+		key, err := json.Marshal(prop.name)
+		if err != nil {
+			return nil, err
+		}
+		value, err := json.Marshal(prop.schema)
+		if err != nil {
+			return nil, err
+		}
+		buf.Write(key)
+		buf.WriteByte(':')
+		buf.Write(value)
+	}
+	buf.WriteByte('}')
+	return buf.Bytes(), nil
+}
+
+func (p properties) MarshalYAML() (any, error) {
+	node := &yaml.Node{Kind: yaml.MappingNode}
+	for _, prop := range p {
+		var value yaml.Node
+		if err := value.Encode(prop.schema); err != nil {
+			return nil, err
+		}
+		node.Content = append(node.Content, &yaml.Node{Kind: yaml.ScalarNode, Value: prop.name}, &value)
+	}
+	return node, nil
+}
+
+func (p properties) without(names map[string]bool) properties {
+	var filtered properties
+	for _, prop := range p {
+		if !names[prop.name] {
+			filtered = append(filtered, prop)
+		}
+	}
+	return filtered
+}
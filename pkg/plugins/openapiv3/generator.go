@@ -0,0 +1,449 @@
+// Package openapiv3 generates OpenAPI 3.1 documents from the google.api.http
+// bindings of gRPC services, in the same way the grpc-gateway generator
+// produces Swagger 2.0 definitions.
+package openapiv3
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/kralicky/grpc-gateway/v2/pkg/descriptor"
+	"github.com/kralicky/ragu/pkg/util"
+	"google.golang.org/protobuf/compiler/protogen"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"gopkg.in/yaml.v3"
+)
+
+const openapiVersion = "3.1.0"
+
+type Format string
+
+const (
+	FormatYAML Format = "yaml"
+	FormatJSON Format = "json"
+)
+
+var Generator = generator{}
+
+type Options struct {
+	// Opt is a protoc-style parameter string. In addition to the standard
+	// protogen parameters, "format", "merge_file_name", "title", "version",
+	// and "grpc_api_configuration" are accepted, corresponding to the fields
+	// below. Parameters in Opt take precedence over the fields below.
+	Opt string
+	// Format of the generated documents. Defaults to YAML.
+	Format Format
+	// MergeFileName, if set, merges all services into a single document with
+	// this name (without extension) instead of generating one document per
+	// proto file.
+	MergeFileName string
+	// Title of the generated documents. Defaults to the name of the proto
+	// file, or MergeFileName for merged documents.
+	Title string
+	// Version of the generated documents. Defaults to "version not set".
+	Version string
+	// GrpcAPIConfiguration is the path to a gRPC API Configuration file in
+	// YAML format, containing HTTP rules for methods that are not annotated
+	// in the proto sources.
+	GrpcAPIConfiguration string
+}
+
+func NewGenerator(opts Options) generator {
+	return generator{Options: opts}
+}
+
+type generator struct {
+	Options
+}
+
+func (generator) Name() string {
+	return "openapiv3"
+}
+
+func (g generator) Parameter() string {
+	return g.Opt
+}
+
+func (g generator) Generate(gen *protogen.Plugin) error {
+	opts := g.Options
+	if err := util.ParsePluginParams(gen.Request.GetParameter(), opts.set); err != nil {
+		return err
+	}
+	switch opts.Format {
+	case "":
+		opts.Format = FormatYAML
+	case FormatYAML, FormatJSON:
+	default:
+		return fmt.Errorf("openapiv3: unknown format %q", opts.Format)
+	}
+	if opts.Version == "" {
+		opts.Version = "version not set"
+	}
+
+	reg := descriptor.NewRegistry()
+	if opts.GrpcAPIConfiguration != "" {
+		if err := reg.LoadGrpcAPIServiceFromYAML(opts.GrpcAPIConfiguration); err != nil {
+			return err
+		}
+	}
+	if err := reg.LoadFromPlugin(gen); err != nil {
+		return err
+	}
+
+	builder := newSchemaBuilder(gen.Files)
+	var merged *document
+	for _, f := range gen.Files {
+		if !f.Generate {
+			continue
+		}
+		file, err := reg.LookupFile(f.Desc.Path())
+		if err != nil {
+			return err
+		}
+		if !hasBindings(file) {
+			continue
+		}
+		if opts.MergeFileName != "" {
+			if merged == nil {
+				merged = newDocument(opts, opts.MergeFileName)
+			}
+			if err := builder.addServices(merged, file); err != nil {
+				return err
+			}
+			continue
+		}
+
+		doc := newDocument(opts, f.Desc.Path())
+		if err := builder.addServices(doc, file); err != nil {
+			return err
+		}
+		if err := writeDocument(gen, doc, opts.Format, f.GeneratedFilenamePrefix, f.GoImportPath); err != nil {
+			return err
+		}
+	}
+	if merged != nil {
+		if err := writeDocument(gen, merged, opts.Format, opts.MergeFileName, ""); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (o *Options) set(name, value string) error {
+	switch name {
+	case "format":
+		o.Format = Format(value)
+	case "merge_file_name":
+		o.MergeFileName = value
+	case "title":
+		o.Title = value
+	case "version":
+		o.Version = value
+	case "grpc_api_configuration":
+		o.GrpcAPIConfiguration = value
+	default:
+		return fmt.Errorf("openapiv3: unknown parameter %q", name)
+	}
+	return nil
+}
+
+func newDocument(opts Options, defaultTitle string) *document {
+	title := opts.Title
+	if title == "" {
+		title = defaultTitle
+	}
+	return &document{
+		OpenAPI: openapiVersion,
+		Info: info{
+			Title:   title,
+			Version: opts.Version,
+		},
+		Paths: map[string]*pathItem{},
+		Components: components{
+			Schemas: map[string]*schema{},
+		},
+	}
+}
+
+func writeDocument(gen *protogen.Plugin, doc *document, format Format, prefix string, importPath protogen.GoImportPath) error {
+	var data []byte
+	var err error
+	switch format {
+	case FormatJSON:
+		data, err = json.MarshalIndent(doc, "", "  ")
+	case FormatYAML:
+		data, err = yaml.Marshal(doc)
+	}
+	if err != nil {
+		return fmt.Errorf("openapiv3: failed to encode %s: %w", prefix, err)
+	}
+	genFile := gen.NewGeneratedFile(prefix+".openapi."+string(format), importPath)
+	_, err = genFile.Write(data)
+	return err
+}
+
+func hasBindings(file *descriptor.File) bool {
+	for _, svc := range file.Services {
+		for _, m := range svc.Methods {
+			if len(m.Bindings) > 0 {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// addServices adds an operation to the document for each HTTP binding of
+// each service in the file. Bindings that cannot be added are skipped, and
+// reported together in the returned error.
+func (b *schemaBuilder) addServices(doc *document, file *descriptor.File) error {
+	b.schemas = doc.Components.Schemas
+	var errs []error
+	for _, svc := range file.Services {
+		hasBindings := false
+		for _, m := range svc.Methods {
+			for _, binding := range m.Bindings {
+				op, err := b.operation(svc, m, binding)
+				if err != nil {
+					errs = append(errs, err)
+					continue
+				}
+				path := openapiPath(binding.PathTmpl.Template)
+				item, ok := doc.Paths[path]
+				if !ok {
+					item = &pathItem{}
+				}
+				slot := item.slot(binding.HTTPMethod)
+				if slot == nil {
+					errs = append(errs, fmt.Errorf("openapiv3: %s: unsupported HTTP method %s in binding %s %s (supported methods are GET, PUT, POST, DELETE, OPTIONS, HEAD, PATCH, and TRACE)",
+						m.FQMN(), binding.HTTPMethod, binding.HTTPMethod, binding.PathTmpl.Template))
+					continue
+				}
+				if *slot != nil {
+					errs = append(errs, fmt.Errorf("openapiv3: %s: binding %s %s conflicts with operation %s, which is also bound to %s %s",
+						m.FQMN(), binding.HTTPMethod, binding.PathTmpl.Template, (*slot).OperationID, binding.HTTPMethod, path))
+					continue
+				}
+				*slot = op
+				doc.Paths[path] = item
+			}
+			hasBindings = hasBindings || len(m.Bindings) > 0
+		}
+		if hasBindings {
+			t := tag{Name: svc.GetName()}
+			if service := b.service(svc); service != nil {
+				t.Description = formatComments(service.Comments.Leading)
+			}
+			doc.Tags = append(doc.Tags, t)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+func (b *schemaBuilder) operation(svc *descriptor.Service, m *descriptor.Method, binding *descriptor.Binding) (*operation, error) {
+	input := b.message(m.RequestType.FQMN())
+	output := b.message(m.ResponseType.FQMN())
+	if input == nil || output == nil {
+		return nil, fmt.Errorf("openapiv3: %s: could not find request or response message", m.FQMN())
+	}
+
+	operationID := fmt.Sprintf("%s_%s", svc.GetName(), m.GetName())
+	if binding.Index > 0 {
+		operationID = fmt.Sprintf("%s%d", operationID, binding.Index+1)
+	}
+	op := &operation{
+		Tags:        []string{svc.GetName()},
+		OperationID: operationID,
+		Deprecated:  m.GetOptions().GetDeprecated(),
+		Responses:   map[string]*response{},
+	}
+	op.Summary, op.Description = b.methodComments(m)
+
+	// path parameters
+	bound := map[string]bool{}
+	for _, param := range binding.PathParams {
+		bound[param.FieldPath.String()] = true
+		field := fieldByPath(input, param.FieldPath.String())
+		if field == nil {
+			return nil, fmt.Errorf("openapiv3: %s: no field %q in %s", m.FQMN(), param.FieldPath.String(), input.Desc.FullName())
+		}
+		s := b.fieldSchema(field)
+		op.Parameters = append(op.Parameters, &parameter{
+			Name:        param.FieldPath.String(),
+			In:          "path",
+			Description: s.Description,
+			Required:    true,
+			Schema:      withoutDescription(s),
+		})
+	}
+
+	// request body
+	if binding.Body != nil {
+		var bodySchema *schema
+		if len(binding.Body.FieldPath) == 0 {
+			bodySchema = b.messageRef(input)
+			if len(binding.PathParams) > 0 && bodySchema.Ref != "" {
+				// fields bound to the path are not part of the body
+				topLevel := map[string]bool{}
+				for _, param := range binding.PathParams {
+					if len(param.FieldPath) == 1 {
+						topLevel[jsonName(input, param.FieldPath[0].Name)] = true
+					}
+				}
+				bodySchema = &schema{
+					Type:       "object",
+					Properties: b.fieldProperties(input).without(topLevel),
+				}
+			}
+		} else {
+			field := fieldByPath(input, binding.Body.FieldPath.String())
+			if field == nil {
+				return nil, fmt.Errorf("openapiv3: %s: no field %q in %s", m.FQMN(), binding.Body.FieldPath.String(), input.Desc.FullName())
+			}
+			bound[binding.Body.FieldPath.String()] = true
+			bodySchema = b.fieldSchema(field)
+		}
+		op.RequestBody = &requestBody{
+			Content:  map[string]mediaType{"application/json": {Schema: bodySchema}},
+			Required: true,
+		}
+	}
+
+	// query parameters
+	if binding.Body == nil || len(binding.Body.FieldPath) > 0 {
+		op.Parameters = append(op.Parameters, b.queryParameters(input, "", bound, map[string]bool{})...)
+	}
+
+	// responses
+	responseSchema := b.messageRef(output)
+	if binding.ResponseBody != nil && len(binding.ResponseBody.FieldPath) > 0 {
+		if field := fieldByPath(output, binding.ResponseBody.FieldPath.String()); field != nil {
+			responseSchema = b.fieldSchema(field)
+		}
+	}
+	if m.GetServerStreaming() {
+		responseSchema = &schema{
+			Type:  "object",
+			Title: fmt.Sprintf("Stream result of %s", output.Desc.FullName()),
+			Properties: properties{
+				{name: "result", schema: responseSchema},
+				{name: "error", schema: b.statusRef()},
+			},
+		}
+	}
+	op.Responses["200"] = &response{
+		Description: "A successful response.",
+		Content:     map[string]mediaType{"application/json": {Schema: responseSchema}},
+	}
+	op.Responses["default"] = &response{
+		Description: "An unexpected error response.",
+		Content:     map[string]mediaType{"application/json": {Schema: b.statusRef()}},
+	}
+	return op, nil
+}
+
+// queryParameters returns query parameters for all fields of the message
+// which are not bound to the path or body. Fields of nested messages are
+// flattened using dot-separated names.
+func (b *schemaBuilder) queryParameters(msg *protogen.Message, prefix string, bound map[string]bool, visited map[string]bool) []*parameter {
+	if visited[string(msg.Desc.FullName())] {
+		return nil
+	}
+	visited[string(msg.Desc.FullName())] = true
+	defer delete(visited, string(msg.Desc.FullName()))
+
+	var params []*parameter
+	for _, field := range msg.Fields {
+		name := prefix + string(field.Desc.Name())
+		if bound[name] || field.Desc.IsMap() {
+			continue
+		}
+		if field.Desc.Kind() == protoreflect.MessageKind && !field.Desc.IsList() {
+			if _, ok := wellKnownSchema(field.Message.Desc.FullName()); !ok {
+				params = append(params, b.queryParameters(field.Message, name+".", bound, visited)...)
+				continue
+			}
+		}
+		if field.Desc.Kind() == protoreflect.MessageKind && field.Desc.IsList() {
+			// repeated messages can't be represented as query parameters
+			continue
+		}
+		s := b.fieldSchema(field)
+		params = append(params, &parameter{
+			Name:        name,
+			In:          "query",
+			Description: s.Description,
+			Deprecated:  s.Deprecated,
+			Schema:      withoutDescription(s),
+		})
+	}
+	return params
+}
+
+// fieldByPath resolves a dot-separated field path relative to a message.
+func fieldByPath(msg *protogen.Message, fieldPath string) *protogen.Field {
+	var field *protogen.Field
+	for _, name := range strings.Split(fieldPath, ".") {
+		if msg == nil {
+			return nil
+		}
+		field = nil
+		for _, f := range msg.Fields {
+			if string(f.Desc.Name()) == name {
+				field = f
+				break
+			}
+		}
+		if field == nil {
+			return nil
+		}
+		msg = field.Message
+	}
+	return field
+}
+
+func jsonName(msg *protogen.Message, fieldName string) string {
+	if field := fieldByPath(msg, fieldName); field != nil {
+		return field.Desc.JSONName()
+	}
+	return fieldName
+}
+
+func withoutDescription(s *schema) *schema {
+	c := *s
+	c.Description = ""
+	c.Deprecated = false
+	return &c
+}
+
+var pathVariable = regexp.MustCompile(`\{([^}=]+)(=[^}]*)?\}`)
+
+// openapiPath converts a google.api.http path template into an OpenAPI
+// path, e.g. "/v1/{name=projects/*}" becomes "/v1/{name}".
+func openapiPath(template string) string {
+	return pathVariable.ReplaceAllString(template, "{$1}")
+}
+
+// methodComments splits the comments of a method into a summary and a
+// description. If the comments contain more than one paragraph, the first
+// paragraph is used as the summary.
+func (b *schemaBuilder) methodComments(m *descriptor.Method) (summary, description string) {
+	svc := b.service(m.Service)
+	if svc == nil {
+		return "", ""
+	}
+	for _, method := range svc.Methods {
+		if string(method.Desc.Name()) != m.GetName() {
+			continue
+		}
+		comments := formatComments(method.Comments.Leading)
+		if paragraphs := strings.SplitN(comments, "\n\n", 2); len(paragraphs) == 2 {
+			return paragraphs[0], paragraphs[1]
+		}
+		return "", comments
+	}
+	return "", ""
+}
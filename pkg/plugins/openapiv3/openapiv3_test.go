@@ -0,0 +1,96 @@
+package openapiv3_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/kralicky/ragu"
+	"github.com/kralicky/ragu/pkg/plugins/openapiv3"
+	"gopkg.in/yaml.v3"
+)
+
+func TestDocument(t *testing.T) {
+	out, err := ragu.GenerateCode([]ragu.Generator{openapiv3.Generator}, "../../../testdata/stream1/*.proto")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(out) != 1 || out[0].Name != "stream_1.openapi.yaml" {
+		t.Fatalf("expected stream_1.openapi.yaml to be generated, got %v", out)
+	}
+	var doc struct {
+		OpenAPI string `yaml:"openapi"`
+		Paths   map[string]map[string]struct {
+			OperationID string `yaml:"operationId"`
+			Parameters  []struct {
+				Name     string
+				In       string
+				Required bool
+			}
+		}
+	}
+	if err := yaml.Unmarshal([]byte(out[0].Content), &doc); err != nil {
+		t.Fatal(err)
+	}
+	if doc.OpenAPI != "3.1.0" || len(doc.Paths) != 2 {
+		t.Fatalf("unexpected document:\n%s", out[0].Content)
+	}
+	for path, id := range map[string]string{
+		"/v1/items/{name}": "Streamer_Unary",
+		"/v1/watch/{name}": "Streamer_ServerStream",
+	} {
+		op := doc.Paths[path]["get"]
+		if op.OperationID != id {
+			t.Fatalf("expected GET %s to be %s, got %q", path, id, op.OperationID)
+		}
+		// fields that are not bound to the path are query parameters
+		if len(op.Parameters) != 2 ||
+			op.Parameters[0].Name != "name" || op.Parameters[0].In != "path" || !op.Parameters[0].Required ||
+			op.Parameters[1].Name != "count" || op.Parameters[1].In != "query" || op.Parameters[1].Required {
+			t.Fatalf("unexpected parameters for %s: %+v", path, op.Parameters)
+		}
+	}
+}
+
+func TestMethods(t *testing.T) {
+	out, err := ragu.GenerateCode([]ragu.Generator{openapiv3.Generator}, "testdata/methods/*.proto")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var doc struct {
+		Paths map[string]map[string]struct {
+			OperationID string `yaml:"operationId"`
+		}
+	}
+	if err := yaml.Unmarshal([]byte(out[0].Content), &doc); err != nil {
+		t.Fatal(err)
+	}
+	ops := doc.Paths["/v1/items/{name}"]
+	for method, id := range map[string]string{
+		"get":     "Methods_Get",
+		"head":    "Methods_Get2",
+		"options": "Methods_Options",
+		"trace":   "Methods_Trace",
+	} {
+		if ops[method].OperationID != id {
+			t.Fatalf("expected %s /v1/items/{name} to be %s, got:\n%s", method, id, out[0].Content)
+		}
+	}
+}
+
+func TestBindingErrors(t *testing.T) {
+	_, err := ragu.GenerateCode([]ragu.Generator{openapiv3.Generator}, "testdata/conflicts/*.proto")
+	if err == nil {
+		t.Fatal("expected an error")
+	}
+	for _, s := range []string{
+		"openapiv3: .conflicts.Conflicts.GetPattern: binding GET /v1/items/{name=*} conflicts with operation Conflicts_Get, which is also bound to GET /v1/items/{name}",
+		"openapiv3: .conflicts.Conflicts.List: unsupported HTTP method LIST in binding LIST /v1/items",
+	} {
+		if !strings.Contains(err.Error(), s) {
+			t.Errorf("expected the error to contain %q, got:\n%v", s, err)
+		}
+	}
+	if strings.Contains(err.Error(), "Conflicts.Post") {
+		t.Errorf("unexpected error for a valid binding:\n%v", err)
+	}
+}
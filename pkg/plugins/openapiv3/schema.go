@@ -0,0 +1,277 @@
+package openapiv3
+
+import (
+	"strings"
+
+	"github.com/kralicky/grpc-gateway/v2/pkg/descriptor"
+	"google.golang.org/genproto/googleapis/api/annotations"
+	"google.golang.org/protobuf/compiler/protogen"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/types/descriptorpb"
+)
+
+const (
+	schemaRefPrefix = "#/components/schemas/"
+	statusName      = "google.rpc.Status"
+)
+
+// schemaBuilder converts protobuf messages and enums into schemas using the
+// proto3 JSON mapping. Messages and enums are added to the components of the
+// document as they are referenced.
+type schemaBuilder struct {
+	services map[protoreflect.FullName]*protogen.Service
+	messages map[protoreflect.FullName]*protogen.Message
+	enums    map[protoreflect.FullName]*protogen.Enum
+	schemas  map[string]*schema
+}
+
+func newSchemaBuilder(files []*protogen.File) *schemaBuilder {
+	b := &schemaBuilder{
+		services: map[protoreflect.FullName]*protogen.Service{},
+		messages: map[protoreflect.FullName]*protogen.Message{},
+		enums:    map[protoreflect.FullName]*protogen.Enum{},
+		schemas:  map[string]*schema{},
+	}
+	var addMessages func([]*protogen.Message)
+	addMessages = func(msgs []*protogen.Message) {
+		for _, msg := range msgs {
+			b.messages[msg.Desc.FullName()] = msg
+			for _, e := range msg.Enums {
+				b.enums[e.Desc.FullName()] = e
+			}
+			addMessages(msg.Messages)
+		}
+	}
+	for _, f := range files {
+		for _, svc := range f.Services {
+			b.services[svc.Desc.FullName()] = svc
+		}
+		for _, e := range f.Enums {
+			b.enums[e.Desc.FullName()] = e
+		}
+		addMessages(f.Messages)
+	}
+	return b
+}
+
+func (b *schemaBuilder) message(name string) *protogen.Message {
+	return b.messages[protoreflect.FullName(strings.TrimPrefix(name, "."))]
+}
+
+func (b *schemaBuilder) service(svc *descriptor.Service) *protogen.Service {
+	return b.services[protoreflect.FullName(strings.TrimPrefix(svc.FQSN(), "."))]
+}
+
+// messageRef returns a reference to the schema for the given message. Well
+// known types are inlined according to their special JSON representation.
+func (b *schemaBuilder) messageRef(msg *protogen.Message) *schema {
+	if s, ok := wellKnownSchema(msg.Desc.FullName()); ok {
+		return s
+	}
+	name := string(msg.Desc.FullName())
+	if _, ok := b.schemas[name]; !ok {
+		// store a placeholder first to handle recursive messages
+		s := &schema{}
+		b.schemas[name] = s
+		*s = b.messageSchema(msg)
+	}
+	return &schema{Ref: schemaRefPrefix + name}
+}
+
+func (b *schemaBuilder) messageSchema(msg *protogen.Message) schema {
+	s := schema{
+		Type:        "object",
+		Description: formatComments(msg.Comments.Leading),
+		Deprecated:  msg.Desc.Options().(*descriptorpb.MessageOptions).GetDeprecated(),
+		Properties:  b.fieldProperties(msg),
+	}
+	for _, field := range msg.Fields {
+		if hasFieldBehavior(field, annotations.FieldBehavior_REQUIRED) {
+			s.Required = append(s.Required, field.Desc.JSONName())
+		}
+	}
+	return s
+}
+
+func (b *schemaBuilder) fieldProperties(msg *protogen.Message) properties {
+	props := properties{}
+	for _, field := range msg.Fields {
+		props = append(props, property{
+			name:   field.Desc.JSONName(),
+			schema: b.fieldSchema(field),
+		})
+	}
+	return props
+}
+
+func (b *schemaBuilder) enumRef(enum *protogen.Enum) *schema {
+	if enum.Desc.FullName() == "google.protobuf.NullValue" {
+		return &schema{Type: "null"}
+	}
+	name := string(enum.Desc.FullName())
+	if _, ok := b.schemas[name]; !ok {
+		s := &schema{
+			Type:        "string",
+			Description: formatComments(enum.Comments.Leading),
+		}
+		for _, value := range enum.Values {
+			s.Enum = append(s.Enum, string(value.Desc.Name()))
+		}
+		b.schemas[name] = s
+	}
+	return &schema{Ref: schemaRefPrefix + name}
+}
+
+// fieldSchema returns the schema for a field, including its comments and
+// field behavior annotations.
+func (b *schemaBuilder) fieldSchema(field *protogen.Field) *schema {
+	var s *schema
+	switch {
+	case field.Desc.IsMap():
+		s = &schema{
+			Type:                 "object",
+			AdditionalProperties: b.valueSchema(field.Message.Fields[1]),
+		}
+	case field.Desc.IsList():
+		s = &schema{
+			Type:  "array",
+			Items: b.valueSchema(field),
+		}
+	default:
+		s = b.valueSchema(field)
+	}
+	s.Description = formatComments(field.Comments.Leading)
+	s.Deprecated = field.Desc.Options().(*descriptorpb.FieldOptions).GetDeprecated()
+	s.ReadOnly = hasFieldBehavior(field, annotations.FieldBehavior_OUTPUT_ONLY)
+	s.WriteOnly = hasFieldBehavior(field, annotations.FieldBehavior_INPUT_ONLY)
+	return s
+}
+
+// valueSchema returns the schema for a single value of a field, ignoring
+// its cardinality.
+func (b *schemaBuilder) valueSchema(field *protogen.Field) *schema {
+	switch field.Desc.Kind() {
+	case protoreflect.MessageKind, protoreflect.GroupKind:
+		return b.messageRef(field.Message)
+	case protoreflect.EnumKind:
+		return b.enumRef(field.Enum)
+	default:
+		return scalarSchema(field.Desc.Kind())
+	}
+}
+
+func scalarSchema(kind protoreflect.Kind) *schema {
+	switch kind {
+	case protoreflect.BoolKind:
+		return &schema{Type: "boolean"}
+	case protoreflect.Int32Kind, protoreflect.Sint32Kind, protoreflect.Sfixed32Kind:
+		return &schema{Type: "integer", Format: "int32"}
+	case protoreflect.Uint32Kind, protoreflect.Fixed32Kind:
+		return &schema{Type: "integer", Format: "uint32"}
+	case protoreflect.Int64Kind, protoreflect.Sint64Kind, protoreflect.Sfixed64Kind:
+		// 64-bit integers are encoded as strings in JSON
+		return &schema{Type: "string", Format: "int64"}
+	case protoreflect.Uint64Kind, protoreflect.Fixed64Kind:
+		return &schema{Type: "string", Format: "uint64"}
+	case protoreflect.FloatKind:
+		return &schema{Type: "number", Format: "float"}
+	case protoreflect.DoubleKind:
+		return &schema{Type: "number", Format: "double"}
+	case protoreflect.BytesKind:
+		return &schema{Type: "string", Format: "byte"}
+	default:
+		return &schema{Type: "string"}
+	}
+}
+
+// wellKnownSchema returns the schema for well-known types which have a
+// special JSON representation.
+func wellKnownSchema(name protoreflect.FullName) (*schema, bool) {
+	nullable := func(s *schema) *schema {
+		s.Type = []string{s.Type.(string), "null"}
+		return s
+	}
+	switch name {
+	case "google.protobuf.Timestamp":
+		return &schema{Type: "string", Format: "date-time"}, true
+	case "google.protobuf.Duration":
+		return &schema{Type: "string", Format: "duration"}, true
+	case "google.protobuf.FieldMask":
+		return &schema{Type: "string", Format: "field-mask"}, true
+	case "google.protobuf.Empty":
+		return &schema{Type: "object"}, true
+	case "google.protobuf.Struct":
+		return &schema{Type: "object", AdditionalProperties: &schema{}}, true
+	case "google.protobuf.Value":
+		return &schema{}, true
+	case "google.protobuf.ListValue":
+		return &schema{Type: "array", Items: &schema{}}, true
+	case "google.protobuf.Any":
+		return anySchema(), true
+	case "google.protobuf.DoubleValue":
+		return nullable(scalarSchema(protoreflect.DoubleKind)), true
+	case "google.protobuf.FloatValue":
+		return nullable(scalarSchema(protoreflect.FloatKind)), true
+	case "google.protobuf.Int64Value":
+		return nullable(scalarSchema(protoreflect.Int64Kind)), true
+	case "google.protobuf.UInt64Value":
+		return nullable(scalarSchema(protoreflect.Uint64Kind)), true
+	case "google.protobuf.Int32Value":
+		return nullable(scalarSchema(protoreflect.Int32Kind)), true
+	case "google.protobuf.UInt32Value":
+		return nullable(scalarSchema(protoreflect.Uint32Kind)), true
+	case "google.protobuf.BoolValue":
+		return nullable(scalarSchema(protoreflect.BoolKind)), true
+	case "google.protobuf.StringValue":
+		return nullable(scalarSchema(protoreflect.StringKind)), true
+	case "google.protobuf.BytesValue":
+		return nullable(scalarSchema(protoreflect.BytesKind)), true
+	}
+	return nil, false
+}
+
+func anySchema() *schema {
+	return &schema{
+		Type: "object",
+		Properties: properties{
+			{name: "@type", schema: &schema{Type: "string"}},
+		},
+		AdditionalProperties: &schema{},
+	}
+}
+
+// statusRef returns a reference to the google.rpc.Status schema, which is
+// used for error responses.
+func (b *schemaBuilder) statusRef() *schema {
+	if _, ok := b.schemas[statusName]; !ok {
+		b.schemas[statusName] = &schema{
+			Type: "object",
+			Properties: properties{
+				{name: "code", schema: &schema{Type: "integer", Format: "int32"}},
+				{name: "message", schema: &schema{Type: "string"}},
+				{name: "details", schema: &schema{Type: "array", Items: anySchema()}},
+			},
+		}
+	}
+	return &schema{Ref: schemaRefPrefix + statusName}
+}
+
+func hasFieldBehavior(field *protogen.Field, behavior annotations.FieldBehavior) bool {
+	behaviors, _ := proto.GetExtension(field.Desc.Options(), annotations.E_FieldBehavior).([]annotations.FieldBehavior)
+	for _, b := range behaviors {
+		if b == behavior {
+			return true
+		}
+	}
+	return false
+}
+
+// formatComments converts proto comments into a plain description.
+func formatComments(comments protogen.Comments) string {
+	lines := strings.Split(strings.TrimSpace(string(comments)), "\n")
+	for i, line := range lines {
+		lines[i] = strings.TrimSpace(line)
+	}
+	return strings.Join(lines, "\n")
+}
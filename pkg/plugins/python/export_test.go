@@ -0,0 +1,10 @@
+package python
+
+// RuntimeRequirements are the runtime dependencies of the generated code, at
+// the versions pinned in the generated pyproject.toml, in the same order.
+var RuntimeRequirements = []string{
+	"betterproto==" + betterprotoVersion,
+	"grpclib==" + grpclibVersion,
+	"grpcio==" + grpcioVersion,
+	"pydantic==" + pydanticVersion,
+}
@@ -1,68 +1,421 @@
 package python
 
 import (
-	_ "embed"
-	"encoding/json"
+	"embed"
+	"fmt"
+	"os"
 	"path"
+	"runtime"
+	"strconv"
+	"strings"
+	"sync"
 
 	"github.com/flosch/pongo2/v6"
 	"github.com/jhump/protoreflect/desc"
+	"github.com/kralicky/ragu/pkg/util"
 	"github.com/samber/lo"
 	"google.golang.org/protobuf/compiler/protogen"
 )
 
-//go:embed template.py.j2
-var template []byte
+// default templates of the generated modules and their stubs, which can be
+// extended by user templates
+//
+//go:embed template.py.j2 template.pyi.j2
+var templateFS embed.FS
+
+// Names of the default templates, for use in {% extends %} tags.
+const (
+	DefaultTemplate     = "template.py.j2"
+	DefaultStubTemplate = "template.pyi.j2"
+)
+
+// support code imported by the generated files, written to each output
+// directory
+//
+//go:embed ragu_wkt.py
+var wktModule []byte
 
 var Generator = generator{}
 
-type generator struct{}
+// Layouts of the generated python code.
+const (
+	// LayoutFile generates a <name>_pb.py module next to each proto file.
+	LayoutFile = "file"
+	// LayoutPackage generates a python package for each proto package, in
+	// the same way as python-betterproto.
+	LayoutPackage = "package"
+)
+
+type Options struct {
+	// Opt is a protoc-style parameter string. In addition to the standard
+	// protogen parameters, "layout=<file|package>", "output_dir=<dir>",
+	// "grpclib=<bool>", "grpcio=<bool>", "pydantic_dataclasses=<bool>",
+	// "stubs=<bool>", "package_name=<name>", "package_version=<version>",
+	// "template=<file>", and "stub_template=<file>" are accepted. Parameters in Opt
+	// take precedence over the fields below.
+	Opt string
+	// DiagnosticHook is called for each construct that could not be
+	// generated, such as a field of an unsupported type. The construct is
+	// omitted, and the rest of the file is still generated. If nil,
+	// diagnostics are written to stderr.
+	DiagnosticHook func(Diagnostic)
+	// Layout is the layout of the generated code, either LayoutFile or
+	// LayoutPackage. Defaults to LayoutFile.
+	Layout string
+	// OutputDir is the directory the package tree is generated into when
+	// Layout is LayoutPackage, relative to the working directory. The
+	// directory is itself a python package, which is the parent of the
+	// top-level proto packages. Defaults to the working directory.
+	OutputDir string
+	// Grpclib controls whether asyncio client stubs and service base classes
+	// are generated for grpclib, as by python-betterproto. Defaults to true.
+	Grpclib *bool
+	// Grpcio additionally generates synchronous client stubs and servicer
+	// base classes for grpcio, named <Service>SyncStub and <Service>Servicer,
+	// and an add_<Service>Servicer_to_server function.
+	Grpcio bool
+	// PydanticDataclasses generates messages as pydantic dataclasses, which
+	// validate their fields when constructed and can export a JSON schema, in
+	// the same way as the pydantic_dataclasses option of python-betterproto.
+	// Struct, Value, ListValue, and FieldMask fields are not converted to
+	// plain python values in this mode.
+	PydanticDataclasses bool
+	// Stubs generates a .pyi type stub next to each generated module.
+	Stubs bool
+	// PackageName, if set, generates a pyproject.toml for a distribution
+	// package of that name, and a py.typed marker. The distribution contains
+	// the package tree in OutputDir, and pyproject.toml is generated in its
+	// parent directory. Requires LayoutPackage and an OutputDir other than
+	// the working directory.
+	PackageName string
+	// PackageVersion is the version of the distribution package. Defaults to
+	// 0.1.0.
+	PackageVersion string
+	// Template is a pongo2 template that replaces the default template of the
+	// generated modules. It can extend the default template with
+	// {% extends "template.py.j2" %} and override its blocks: header, imports,
+	// message_decorators, message_bases, message_methods, and footer. The
+	// template is executed with the Model of each module as Model, and its
+	// OutputFile as OutputFile.
+	Template string
+	// StubTemplate is a pongo2 template that replaces the default template of
+	// the .pyi stubs, in the same way as Template. The default stub template,
+	// "template.pyi.j2", has the header, imports, message_bases, and footer
+	// blocks.
+	StubTemplate string
+}
+
+// NewGenerator returns a python generator with the given options.
+func NewGenerator(opts Options) generator {
+	return generator{Options: opts}
+}
+
+type generator struct {
+	Options
+}
 
 func (generator) Name() string {
 	return "python"
 }
 
-func (generator) Generate(gen *protogen.Plugin) error {
-	tpl, err := pongo2.FromBytes(template)
+func (g generator) Parameter() string {
+	return g.Opt
+}
+
+// config holds the settings for a single call to Generate.
+type config struct {
+	layout    string
+	outputDir string
+	grpclib   bool
+	grpcio    bool
+	pydantic  bool
+	stubs     bool
+
+	packageName    string
+	packageVersion string
+
+	template     string
+	stubTemplate string
+}
+
+func (g generator) config(param string) (config, error) {
+	cfg := config{
+		layout:    lo.Ternary(g.Layout != "", g.Layout, LayoutFile),
+		outputDir: lo.Ternary(g.OutputDir != "", g.OutputDir, "."),
+		grpclib:   g.Grpclib == nil || *g.Grpclib,
+		grpcio:    g.Grpcio,
+		pydantic:  g.PydanticDataclasses,
+		stubs:     g.Stubs,
+
+		packageName:    g.PackageName,
+		packageVersion: lo.Ternary(g.PackageVersion != "", g.PackageVersion, "0.1.0"),
+
+		template:     g.Template,
+		stubTemplate: g.StubTemplate,
+	}
+	if err := util.ParsePluginParams(param, cfg.set); err != nil {
+		return config{}, err
+	}
+	switch cfg.layout {
+	case LayoutFile, LayoutPackage:
+	default:
+		return config{}, fmt.Errorf("python: unknown layout %q", cfg.layout)
+	}
+	if cfg.packageName != "" && (cfg.layout != LayoutPackage || path.Clean(cfg.outputDir) == ".") {
+		return config{}, fmt.Errorf("python: package_name requires layout=package and an output_dir")
+	}
+	return cfg, nil
+}
+
+func (c *config) set(name, value string) error {
+	var err error
+	switch name {
+	case "layout":
+		c.layout = value
+	case "output_dir":
+		c.outputDir = value
+	case "grpclib":
+		c.grpclib, err = parseBool(value)
+	case "grpcio":
+		c.grpcio, err = parseBool(value)
+	case "pydantic_dataclasses":
+		c.pydantic, err = parseBool(value)
+	case "stubs":
+		c.stubs, err = parseBool(value)
+	case "package_name":
+		c.packageName = value
+	case "package_version":
+		c.packageVersion = value
+	case "template":
+		c.template, err = readTemplate(value)
+	case "stub_template":
+		c.stubTemplate, err = readTemplate(value)
+	default:
+		return fmt.Errorf("python: unknown parameter %q", name)
+	}
+	if err != nil {
+		return fmt.Errorf("python: bad value for parameter %q: %w", name, err)
+	}
+	return nil
+}
+
+// parseBool parses a boolean parameter value. As with protoc flags, an empty
+// value means true.
+func parseBool(value string) (bool, error) {
+	if value == "" {
+		return true, nil
+	}
+	return strconv.ParseBool(value)
+}
+
+// readTemplate reads a template file named by a parameter.
+func readTemplate(filename string) (string, error) {
+	data, err := os.ReadFile(filename)
+	if err != nil {
+		return "", err
+	}
+	return string(data), nil
+}
+
+func (g generator) Generate(gen *protogen.Plugin) error {
+	cfg, err := g.config(gen.Request.GetParameter())
 	if err != nil {
 		return err
 	}
-	dirs := map[string]struct{}{}
+	tpls, err := parseTemplates(cfg)
+	if err != nil {
+		return err
+	}
+	fd, err := desc.CreateFileDescriptors(gen.Request.ProtoFile)
+	if err != nil {
+		return err
+	}
+	if cfg.layout == LayoutPackage {
+		return g.generatePackages(gen, fd, tpls, cfg)
+	}
+	var modules []module
+	var dirs []string
 	for _, f := range gen.Files {
 		if f.Generate {
-			dirs[path.Dir(f.GeneratedFilenamePrefix)] = struct{}{}
-			filename := f.GeneratedFilenamePrefix + "_pb.py"
-			g := gen.NewGeneratedFile(filename, "")
+			dirs = append(dirs, path.Dir(f.GeneratedFilenamePrefix))
+			modules = append(modules, module{
+				filename: f.GeneratedFilenamePrefix + "_pb.py",
+				files:    []*desc.FileDescriptor{fd[f.Proto.GetName()]},
+			})
+		}
+	}
+	if err := g.writeModules(gen, modules, tpls, cfg); err != nil {
+		return err
+	}
+	for _, dir := range lo.Uniq(dirs) {
+		gen.NewGeneratedFile(path.Join(dir, "__init__.py"), "")
+		if _, err := gen.NewGeneratedFile(path.Join(dir, "ragu_wkt.py"), "").Write(wktModule); err != nil {
+			return err
+		}
+	}
 
-			fd, err := desc.CreateFileDescriptors(gen.Request.ProtoFile)
-			if err != nil {
-				return err
-			}
+	return nil
+}
 
-			model := buildModel(fd[f.Proto.GetName()], lo.Values(fd))
-			jsonData, err := json.Marshal(model)
-			if err != nil {
-				return err
+// templates are the templates of the generated modules and their stubs.
+type templates struct {
+	module *pongo2.Template
+	stub   *pongo2.Template
+}
+
+// parseTemplates parses the configured templates, or the default ones. User
+// templates can extend the default templates by name.
+func parseTemplates(cfg config) (templates, error) {
+	set := pongo2.NewSet("python", pongo2.NewFSLoader(templateFS))
+	parse := func(name, userTemplate string) (*pongo2.Template, error) {
+		if userTemplate == "" {
+			return set.FromFile(name)
+		}
+		return set.FromString(userTemplate)
+	}
+	module, err := parse(DefaultTemplate, cfg.template)
+	if err != nil {
+		return templates{}, fmt.Errorf("python: template: %w", err)
+	}
+	stub, err := parse(DefaultStubTemplate, cfg.stubTemplate)
+	if err != nil {
+		return templates{}, fmt.Errorf("python: stub template: %w", err)
+	}
+	return templates{module: module, stub: stub}, nil
+}
+
+// module is a generated python module, containing the types in one or more
+// proto files.
+type module struct {
+	filename string
+	files    []*desc.FileDescriptor
+}
+
+// writeModules builds and renders the modules in parallel, then reports their
+// diagnostics and writes them, along with their .pyi stubs if enabled, in
+// order.
+func (g generator) writeModules(gen *protogen.Plugin, modules []module, tpls templates, cfg config) error {
+	type result struct {
+		model      *Model
+		data, stub []byte
+		err        error
+	}
+	results := make([]result, len(modules))
+	sem := make(chan struct{}, runtime.GOMAXPROCS(0))
+	var wg sync.WaitGroup
+	for i, mod := range modules {
+		wg.Add(1)
+		sem <- struct{}{}
+		go func(r *result, mod module) {
+			defer func() {
+				<-sem
+				wg.Done()
+			}()
+			r.model = buildModel(mod.files, cfg)
+			if r.data, r.err = render(tpls.module, r.model); r.err != nil {
+				return
 			}
-			modelMap := map[string]any{}
-			err = json.Unmarshal(jsonData, &modelMap)
-			if err != nil {
-				return err
+			if cfg.stubs {
+				r.stub, r.err = render(tpls.stub, r.model)
 			}
+		}(&results[i], mod)
+	}
+	wg.Wait()
 
-			data, err := tpl.ExecuteBytes(modelMap)
-			if err != nil {
+	for i, r := range results {
+		for _, d := range r.model.Diagnostics {
+			g.report(d)
+		}
+		if r.err != nil {
+			return r.err
+		}
+		filename := modules[i].filename
+		if _, err := gen.NewGeneratedFile(filename, "").Write(r.data); err != nil {
+			return err
+		}
+		if r.stub != nil {
+			if _, err := gen.NewGeneratedFile(filename+"i", "").Write(r.stub); err != nil {
 				return err
 			}
+		}
+	}
+	return nil
+}
 
-			if _, err := g.Write(data); err != nil {
-				return err
+// generatePackages generates a python package for each proto package, with
+// the types of all files in the package defined in its __init__.py. Parent
+// packages without types of their own are generated empty.
+func (g generator) generatePackages(gen *protogen.Plugin, fd map[string]*desc.FileDescriptor, tpls templates, cfg config) error {
+	var packages []string
+	filesByPackage := map[string][]*desc.FileDescriptor{}
+	for _, f := range gen.Files {
+		if !f.Generate {
+			continue
+		}
+		pkg := f.Proto.GetPackage()
+		if _, ok := filesByPackage[pkg]; !ok {
+			packages = append(packages, pkg)
+		}
+		filesByPackage[pkg] = append(filesByPackage[pkg], fd[f.Proto.GetName()])
+	}
+
+	var modules []module
+	generated := map[string]bool{}
+	for _, pkg := range packages {
+		dir := packageDir(cfg.outputDir, pkg)
+		modules = append(modules, module{
+			filename: path.Join(dir, "__init__.py"),
+			files:    filesByPackage[pkg],
+		})
+		generated[dir] = true
+	}
+	if err := g.writeModules(gen, modules, tpls, cfg); err != nil {
+		return err
+	}
+	for _, pkg := range packages {
+		for dir := packageDir(cfg.outputDir, pkg); ; dir = path.Dir(dir) {
+			if !generated[dir] {
+				gen.NewGeneratedFile(path.Join(dir, "__init__.py"), "")
+				generated[dir] = true
+			}
+			if dir == path.Clean(cfg.outputDir) {
+				break
 			}
 		}
 	}
-	for dir := range dirs {
-		gen.NewGeneratedFile(path.Join(dir, "__init__.py"), "")
+	if _, err := gen.NewGeneratedFile(path.Join(cfg.outputDir, "ragu_wkt.py"), "").Write(wktModule); err != nil {
+		return err
+	}
+	if cfg.packageName != "" {
+		gen.NewGeneratedFile(path.Join(cfg.outputDir, "py.typed"), "")
+		outputDir := path.Clean(cfg.outputDir)
+		if _, err := gen.NewGeneratedFile(path.Join(path.Dir(outputDir), "pyproject.toml"), "").Write(pyproject(cfg, path.Base(outputDir))); err != nil {
+			return err
+		}
 	}
-
 	return nil
 }
+
+// packageDir returns the directory of the python package for a proto package.
+func packageDir(outputDir, pkg string) string {
+	if pkg == "" {
+		return path.Clean(outputDir)
+	}
+	return path.Join(outputDir, strings.ReplaceAll(pkg, ".", "/"))
+}
+
+// render executes the template with the model.
+func render(tpl *pongo2.Template, model *Model) ([]byte, error) {
+	return tpl.ExecuteBytes(pongo2.Context{
+		"Model":      model,
+		"OutputFile": model.OutputFile,
+	})
+}
+
+func (g generator) report(d Diagnostic) {
+	if g.DiagnosticHook != nil {
+		g.DiagnosticHook(d)
+		return
+	}
+	fmt.Fprintf(os.Stderr, "python: %v\n", d)
+}
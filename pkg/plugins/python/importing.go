@@ -13,16 +13,53 @@ import (
 
 // https://github.com/danielgtaylor/python-betterproto/blob/master/src/betterproto/compile/importing.py
 
+// wrapperTypes maps wrapper types to the python type of their value.
 var wrapperTypes = map[string]string{
-	"google.protobuf.DoubleValue": "google_protobuf.DoubleValue",
-	"google.protobuf.FloatValue":  "google_protobuf.FloatValue",
-	"google.protobuf.Int32Value":  "google_protobuf.Int32Value",
-	"google.protobuf.Int64Value":  "google_protobuf.Int64Value",
-	"google.protobuf.UInt32Value": "google_protobuf.UInt32Value",
-	"google.protobuf.UInt64Value": "google_protobuf.UInt64Value",
-	"google.protobuf.BoolValue":   "google_protobuf.BoolValue",
-	"google.protobuf.StringValue": "google_protobuf.StringValue",
-	"google.protobuf.BytesValue":  "google_protobuf.BytesValue",
+	"google.protobuf.DoubleValue": "float",
+	"google.protobuf.FloatValue":  "float",
+	"google.protobuf.Int32Value":  "int",
+	"google.protobuf.Int64Value":  "int",
+	"google.protobuf.UInt32Value": "int",
+	"google.protobuf.UInt64Value": "int",
+	"google.protobuf.BoolValue":   "bool",
+	"google.protobuf.StringValue": "str",
+	"google.protobuf.BytesValue":  "bytes",
+}
+
+// wrappedTypes maps wrapper types to the betterproto type of their value,
+// used as the wraps argument of the field.
+var wrappedTypes = map[string]string{
+	"google.protobuf.DoubleValue": "betterproto.TYPE_DOUBLE",
+	"google.protobuf.FloatValue":  "betterproto.TYPE_FLOAT",
+	"google.protobuf.Int32Value":  "betterproto.TYPE_INT32",
+	"google.protobuf.Int64Value":  "betterproto.TYPE_INT64",
+	"google.protobuf.UInt32Value": "betterproto.TYPE_UINT32",
+	"google.protobuf.UInt64Value": "betterproto.TYPE_UINT64",
+	"google.protobuf.BoolValue":   "betterproto.TYPE_BOOL",
+	"google.protobuf.StringValue": "betterproto.TYPE_STRING",
+	"google.protobuf.BytesValue":  "betterproto.TYPE_BYTES",
+}
+
+// wellKnownType describes a well-known type which can be read and written as
+// a plain python value through a generated property, converted by the
+// ragu_wkt support module.
+type wellKnownType struct {
+	// name of the conversion in ragu_wkt, e.g. struct for to_struct and
+	// from_struct
+	convert string
+	// suffix of the property name, appended to the field name
+	suffix string
+	// python type of the value
+	pyType string
+	// names imported from typing by pyType
+	typingImports []string
+}
+
+var wellKnownTypes = map[string]wellKnownType{
+	"google.protobuf.Struct":    {convert: "struct", suffix: "_dict", pyType: "Dict[str, Any]", typingImports: []string{"Any", "Dict"}},
+	"google.protobuf.Value":     {convert: "value", suffix: "_value", pyType: "Any", typingImports: []string{"Any"}},
+	"google.protobuf.ListValue": {convert: "list_value", suffix: "_list", pyType: "List[Any]", typingImports: []string{"Any", "List"}},
+	"google.protobuf.FieldMask": {convert: "field_mask", suffix: "_paths", pyType: "List[str]", typingImports: []string{"List"}},
 }
 
 func parseSourceTypeName(fieldTypeName string) (string, string) {
@@ -67,10 +104,15 @@ func (m *Model) getTypeReference(from, to *desc.FileDescriptor, imports []*desc.
 	case slices.Equal(pyPackage[:1], []string{"betterproto"}):
 		ref, addImports = referenceAbsolute(imports, pyPackage, pyType)
 	case slices.Equal(pyPackage, currentPackage):
+		if m.cfg.layout == LayoutPackage {
+			// all types in the package are in the same module
+			ref = pyType
+			break
+		}
 		ref, addImports = referenceSibling(from, to, pyType)
-	case slices.Equal(pyPackage[:len(currentPackage)], currentPackage):
+	case len(pyPackage) > len(currentPackage) && slices.Equal(pyPackage[:len(currentPackage)], currentPackage):
 		ref, addImports = referenceDescendent(currentPackage, imports, pyPackage, pyType)
-	case slices.Equal(currentPackage[:len(pyPackage)], pyPackage):
+	case len(currentPackage) > len(pyPackage) && slices.Equal(currentPackage[:len(pyPackage)], pyPackage):
 		ref, addImports = referenceAncestor(currentPackage, imports, pyPackage, pyType)
 	default:
 		ref, addImports = referenceCousin(currentPackage, imports, pyPackage, pyType)
@@ -11,74 +11,154 @@ import (
 )
 
 type Model struct {
-	OutputFile *OutputFile `json:"output_file"`
+	OutputFile *OutputFile
+	// Diagnostics describes the constructs that could not be generated.
+	Diagnostics []Diagnostic
+
+	cfg config
+}
+
+// Diagnostic describes a construct that could not be generated, such as a
+// field of an unsupported type. The construct is omitted from the output.
+type Diagnostic struct {
+	// Pos is the position of the construct, in the form file:line:column.
+	Pos string
+	// Name is the fully-qualified name of the construct.
+	Name string
+	Err  error
+}
+
+func newDiagnostic(d desc.Descriptor, err error) Diagnostic {
+	pos := d.GetFile().GetName()
+	if span := d.GetSourceInfo().GetSpan(); len(span) >= 2 {
+		pos = fmt.Sprintf("%s:%d:%d", pos, span[0]+1, span[1]+1)
+	}
+	return Diagnostic{
+		Pos:  pos,
+		Name: d.GetFullyQualifiedName(),
+		Err:  err,
+	}
+}
+
+func (d Diagnostic) Error() string {
+	return fmt.Sprintf("%s: %s: %v", d.Pos, d.Name, d.Err)
 }
 
 type OutputFile struct {
-	InputFilenames          []string  `json:"input_filenames"`
-	Imports                 []string  `json:"imports"`
-	DatetimeImports         []string  `json:"datetime_imports"`
-	PythonModuleImports     []string  `json:"python_module_imports"`
-	ImportsTypeCheckingOnly []string  `json:"imports_type_checking_only"`
-	TypingImports           []string  `json:"typing_imports"`
-	Enums                   []Enum    `json:"enums"`
-	Messages                []Message `json:"messages"`
-	Services                []Service `json:"services"`
+	InputFilenames          []string
+	SupportImport           string
+	GrpclibStubs            bool
+	GrpcioStubs             bool
+	PydanticDataclasses     bool
+	PydanticValidators      bool
+	Imports                 []string
+	DatetimeImports         []string
+	PythonModuleImports     []string
+	ImportsTypeCheckingOnly []string
+	TypingImports           []string
+	Enums                   []Enum
+	Messages                []Message
+	Services                []Service
 }
 
 type Enum struct {
-	Comment string  `json:"comment"`
-	PyName  string  `json:"py_name"`
-	Entries []Entry `json:"entries"`
+	Comment string
+	PyName  string
+	Entries []Entry
 }
 
 type Entry struct {
-	Comment string `json:"comment"`
-	Name    string `json:"name"`
-	Value   int32  `json:"value"`
+	Comment string
+	Name    string
+	Value   int32
 }
 
 type Message struct {
-	Comment             string  `json:"comment"`
-	Deprecated          bool    `json:"deprecated"`
-	PyName              string  `json:"py_name"`
-	HasDeprecatedFields bool    `json:"has_deprecated_fields"`
-	DeprecatedFields    []Field `json:"deprecated_fields"`
-	Fields              []Field `json:"fields"`
+	Comment             string
+	Deprecated          bool
+	PyName              string
+	HasDeprecatedFields bool
+	DeprecatedFields    []string
+	Fields              []Field
+	// FullName is the fully-qualified proto name the message is registered
+	// with, for packing and unpacking google.protobuf.Any values.
+	FullName string
+	// WellKnownProperties expose well-known type fields as plain values.
+	WellKnownProperties []WellKnownProperty
+	// HasOneofGroups is set if any field is in a oneof, which is checked by a
+	// validator in pydantic dataclasses.
+	HasOneofGroups bool
 }
 
 type Field struct {
-	FieldString string `json:"field_string"`
-	Comment     string `json:"comment"`
+	FieldString string
+	Comment     string
+	PyName      string
+	PyType      string
+	// Group is the name of the oneof containing the field. It is empty for
+	// proto3 optional fields, whose synthetic oneofs are not generated.
+	Group      string
+	Optional   bool
+	Deprecated bool
+}
+
+// WellKnownProperty is a property which reads and writes a singular
+// well-known type field as a plain python value, e.g. attributes_dict for a
+// google.protobuf.Struct field named attributes.
+type WellKnownProperty struct {
+	PyName  string
+	PyType  string
+	Field   string
+	Convert string
 }
 
 type Service struct {
-	Comment string   `json:"comment"`
-	PyName  string   `json:"py_name"`
-	Methods []Method `json:"methods"`
+	Comment  string
+	PyName   string
+	FullName string
+	Methods  []Method
 }
 
 type Method struct {
-	PyInputMessageParam string `json:"py_input_message_param"`
-	Comment             string `json:"comment"`
-	PyOutputMessageType string `json:"py_output_message_type"`
-	PyInputMessageType  string `json:"py_input_message_type"`
-	PyName              string `json:"py_name"`
-	Route               string `json:"route"`
-	PyInputMessage      string `json:"py_input_message"`
-	ServerStreaming     bool   `json:"server_streaming"`
-	ClientStreaming     bool   `json:"client_streaming"`
+	PyInputMessageParam string
+	Comment             string
+	PyOutputMessageType string
+	PyInputMessageType  string
+	PyName              string
+	ProtoName           string
+	Route               string
+	PyInputMessage      string
+	ServerStreaming     bool
+	ClientStreaming     bool
 }
 
-func buildModel(f *desc.FileDescriptor, deps []*desc.FileDescriptor) *Model {
+// buildModel builds the model of a generated file, containing the types in
+// the given files. With the package layout, the files are all in the same
+// proto package.
+func buildModel(files []*desc.FileDescriptor, cfg config) *Model {
 	m := &Model{
 		OutputFile: &OutputFile{
-			InputFilenames: []string{f.GetName()},
+			SupportImport:       "import ragu_wkt",
+			GrpclibStubs:        cfg.grpclib,
+			GrpcioStubs:         cfg.grpcio,
+			PydanticDataclasses: cfg.pydantic,
 		},
+		cfg: cfg,
+	}
+	if cfg.layout == LayoutPackage {
+		// the support module is in the root package
+		depth := 0
+		if pkg := files[0].GetPackage(); pkg != "" {
+			depth = strings.Count(pkg, ".") + 1
+		}
+		m.OutputFile.SupportImport = fmt.Sprintf("from .%s import ragu_wkt", strings.Repeat(".", depth))
+	}
+	for _, f := range files {
+		m.OutputFile.InputFilenames = append(m.OutputFile.InputFilenames, f.GetName())
+		m.OutputFile.Enums = append(m.OutputFile.Enums, m.buildEnums(f)...)
+		m.OutputFile.Messages = append(m.OutputFile.Messages, m.buildMessages(f)...)
+		m.OutputFile.Services = append(m.OutputFile.Services, m.buildServices(f)...)
 	}
-	m.OutputFile.Enums = m.buildEnums(f)
-	m.OutputFile.Messages = m.buildMessages(f)
-	m.OutputFile.Services = m.buildServices(f)
 	m.OutputFile.cleanImports()
 
 	return m
@@ -87,90 +167,179 @@ func buildModel(f *desc.FileDescriptor, deps []*desc.FileDescriptor) *Model {
 func (m *Model) buildEnums(f *desc.FileDescriptor) []Enum {
 	enums := []Enum{}
 	for _, e := range f.GetEnumTypes() {
-		entries := []Entry{}
-		for _, value := range e.GetValues() {
-			e := Entry{
-				Comment: formatComment(value.GetSourceInfo().GetLeadingComments(), 1),
-				Name:    value.GetName(),
-				Value:   value.GetNumber(),
-			}
-			entries = append(entries, e)
-		}
-		enums = append(enums, Enum{
-			Comment: formatComment(e.GetSourceInfo().GetLeadingComments(), 1),
-			PyName:  formatClassName(e.GetName()),
-			Entries: entries,
-		})
+		enums = append(enums, buildEnum(e))
 	}
-	for _, msg := range f.GetMessageTypes() {
-		for _, nestedEnum := range msg.GetNestedEnumTypes() {
-			entries := []Entry{}
-			for _, value := range nestedEnum.GetValues() {
-				e := Entry{
-					Comment: formatComment(value.GetSourceInfo().GetLeadingComments(), 1),
-					Name:    value.GetName(),
-					Value:   value.GetNumber(),
-				}
-				entries = append(entries, e)
-			}
-			enums = append(enums, Enum{
-				Comment: formatComment(nestedEnum.GetSourceInfo().GetLeadingComments(), 1),
-				PyName:  formatClassName(msg.GetName() + "_" + nestedEnum.GetName()),
-				Entries: entries,
-			})
+	for _, msg := range allMessages(f) {
+		for _, e := range msg.GetNestedEnumTypes() {
+			enums = append(enums, buildEnum(e))
 		}
 	}
 	return enums
 }
 
+func buildEnum(e *desc.EnumDescriptor) Enum {
+	entries := []Entry{}
+	for _, value := range e.GetValues() {
+		entries = append(entries, Entry{
+			Comment: formatComment(value.GetSourceInfo().GetLeadingComments(), 1),
+			Name:    value.GetName(),
+			Value:   value.GetNumber(),
+		})
+	}
+	return Enum{
+		Comment: formatComment(e.GetSourceInfo().GetLeadingComments(), 1),
+		PyName:  pyClassName(e),
+		Entries: entries,
+	}
+}
+
 func (m *Model) buildMessages(f *desc.FileDescriptor) []Message {
 	messages := []Message{}
-	for _, msg := range f.GetMessageTypes() {
+	for _, msg := range allMessages(f) {
 		fields := []Field{}
-		for _, field := range msg.GetFields() {
-			fields = append(fields, m.buildField(field))
+		deprecatedFields := []string{}
+		hasOneofGroups := false
+		for _, fd := range msg.GetFields() {
+			field, err := m.buildField(fd)
+			if err != nil {
+				m.Diagnostics = append(m.Diagnostics, newDiagnostic(fd, err))
+				continue
+			}
+			fields = append(fields, field)
+			if field.Deprecated {
+				deprecatedFields = append(deprecatedFields, field.PyName)
+			}
+			if field.Group != "" {
+				hasOneofGroups = true
+			}
 		}
-		messages = append(messages, Message{
-			Comment:    formatComment(msg.GetSourceInfo().GetLeadingComments(), 1),
-			PyName:     formatClassName(msg.GetName()),
-			Deprecated: msg.GetMessageOptions().GetDeprecated(),
-			Fields:     fields,
+		message := Message{
+			Comment:             formatComment(msg.GetSourceInfo().GetLeadingComments(), 1),
+			PyName:              pyClassName(msg),
+			Deprecated:          msg.GetMessageOptions().GetDeprecated(),
+			HasDeprecatedFields: len(deprecatedFields) > 0,
+			DeprecatedFields:    deprecatedFields,
+			Fields:              fields,
+			FullName:            msg.GetFullyQualifiedName(),
+			WellKnownProperties: m.buildWellKnownProperties(msg, fields),
+			HasOneofGroups:      hasOneofGroups,
+		}
+		if message.Deprecated || message.HasDeprecatedFields {
+			m.OutputFile.PythonModuleImports = append(m.OutputFile.PythonModuleImports, "warnings")
+		}
+		if message.HasOneofGroups && m.cfg.pydantic {
+			m.OutputFile.PydanticValidators = true
+		}
+		messages = append(messages, message)
+	}
+	return messages
+}
+
+// buildWellKnownProperties returns the properties of the singular well-known
+// type fields of a message. A property which would have the same name as a
+// field is omitted.
+func (m *Model) buildWellKnownProperties(msg *desc.MessageDescriptor, fields []Field) []WellKnownProperty {
+	properties := []WellKnownProperty{}
+	for _, fd := range msg.GetFields() {
+		if fd.GetMessageType() == nil || fd.IsRepeated() {
+			continue
+		}
+		wkt, ok := wellKnownTypes[fd.GetMessageType().GetFullyQualifiedName()]
+		if !ok {
+			continue
+		}
+		field := formatFieldName(fd.GetName())
+		name := field + wkt.suffix
+		if slices.ContainsFunc(fields, func(f Field) bool { return f.PyName == name }) {
+			m.Diagnostics = append(m.Diagnostics, newDiagnostic(fd,
+				fmt.Errorf("property %s is omitted, since it has the same name as a field", name)))
+			continue
+		}
+		properties = append(properties, WellKnownProperty{
+			PyName:  name,
+			PyType:  wkt.pyType,
+			Field:   field,
+			Convert: wkt.convert,
 		})
+		m.OutputFile.TypingImports = append(m.OutputFile.TypingImports, wkt.typingImports...)
+	}
+	return properties
+}
+
+// allMessages returns the messages in the file, including nested messages at
+// any depth, with each message followed by its nested messages. Map entry
+// messages are omitted.
+func allMessages(f *desc.FileDescriptor) []*desc.MessageDescriptor {
+	var messages []*desc.MessageDescriptor
+	var walk func([]*desc.MessageDescriptor)
+	walk = func(msgs []*desc.MessageDescriptor) {
+		for _, msg := range msgs {
+			if msg.IsMapEntry() {
+				continue
+			}
+			messages = append(messages, msg)
+			walk(msg.GetNestedMessageTypes())
+		}
 	}
+	walk(f.GetMessageTypes())
 	return messages
 }
 
-func (m *Model) buildField(f *desc.FieldDescriptor) Field {
+// pyClassName returns the class name of a message or enum. Nested types are
+// named after their enclosing types, e.g. Outer.Inner becomes OuterInner.
+func pyClassName(d desc.Descriptor) string {
+	name := strings.TrimPrefix(d.GetFullyQualifiedName(), d.GetFile().GetPackage()+".")
+	return formatClassName(strings.ReplaceAll(name, ".", "_"))
+}
+
+func (m *Model) buildField(f *desc.FieldDescriptor) (Field, error) {
 	name := formatFieldName(f.GetName())
 	annotation := ""
+	fieldArgs := []string{fmt.Sprint(f.GetNumber())}
+	if msg := f.GetMessageType(); msg != nil && !f.IsMap() {
+		if wraps, ok := wrappedTypes[msg.GetFullyQualifiedName()]; ok {
+			fieldArgs = append(fieldArgs, "wraps="+wraps)
+		}
+	}
 	pyType, err := m.pyType(f)
 	if err != nil {
-		panic(err)
+		return Field{}, err
 	}
-	fieldWraps := strings.HasPrefix(f.GetType().String(), "google.protobuf")
-	fieldArgs := []string{fmt.Sprint(f.GetNumber())}
-	if fieldWraps {
-		fieldArgs = append(fieldArgs, "wraps=True")
+	var group string
+	if oneof := f.GetOneOf(); oneof != nil && !oneof.IsSynthetic() {
+		group = oneof.GetName()
+	}
+	optional := f.IsProto3Optional()
+	if optional {
+		fieldArgs = append(fieldArgs, "optional=True")
+	}
+	if group != "" {
+		fieldArgs = append(fieldArgs, fmt.Sprintf("group=%q", group))
 	}
 	var protoFieldType string
 
 	if f.IsMap() {
 		keyType, err := m.pyType(f.GetMapKeyType())
 		if err != nil {
-			panic(err)
+			return Field{}, err
 		}
 		valueType, err := m.pyType(f.GetMapValueType())
 		if err != nil {
-			panic(err)
+			return Field{}, err
 		}
-		annotation = fmt.Sprintf(": Dict[%s, %s]", keyType, valueType)
-		fieldArgs = append(fieldArgs, fmt.Sprintf("key_type=%s", keyType), fmt.Sprintf("value_type=%s", valueType))
+		annotation = fmt.Sprintf("Dict[%s, %s]", keyType, valueType)
+		// betterproto resolves the class of message and enum values from the
+		// annotation, the arguments are the wire types.
+		fieldArgs = append(fieldArgs, betterprotoType(f.GetMapKeyType()), betterprotoType(f.GetMapValueType()))
 		m.OutputFile.TypingImports = append(m.OutputFile.TypingImports, "Dict")
 	} else if f.IsRepeated() {
-		annotation = fmt.Sprintf(": List[%s]", pyType)
+		annotation = fmt.Sprintf("List[%s]", pyType)
 		m.OutputFile.TypingImports = append(m.OutputFile.TypingImports, "List")
+	} else if optional {
+		annotation = fmt.Sprintf("Optional[%s]", pyType)
+		m.OutputFile.TypingImports = append(m.OutputFile.TypingImports, "Optional")
 	} else {
-		annotation = fmt.Sprintf(": %s", pyType)
+		annotation = pyType
 	}
 
 	if f.IsMap() {
@@ -181,15 +350,27 @@ func (m *Model) buildField(f *desc.FieldDescriptor) Field {
 	fieldType := fmt.Sprintf("betterproto.%s_field(%s)", protoFieldType, strings.Join(fieldArgs, ", "))
 	return Field{
 		Comment:     formatComment(f.GetSourceInfo().GetLeadingComments(), 1),
-		FieldString: fmt.Sprintf("%s%s = %s", name, annotation, fieldType),
-	}
+		FieldString: fmt.Sprintf("%s: %s = %s", name, annotation, fieldType),
+		PyType:      annotation,
+		PyName:      name,
+		Group:       group,
+		Optional:    optional,
+		Deprecated:  f.GetFieldOptions().GetDeprecated(),
+	}, nil
 }
 
+// betterprotoType returns the betterproto constant for the wire type of a
+// field, e.g. betterproto.TYPE_STRING.
+func betterprotoType(f *desc.FieldDescriptor) string {
+	return "betterproto." + f.GetType().String()
+}
+
+// messageTypeRef returns a reference to the input or output type of a method.
+// Unlike fields, well-known types are never unwrapped.
 func (m *Model) messageTypeRef(from *desc.FileDescriptor, msg *desc.MessageDescriptor) string {
-	fieldWraps := strings.HasPrefix(msg.GetFullyQualifiedName(), "google.protobuf")
 	return m.getTypeReference(from, msg.GetFile(),
 		msg.GetFile().GetDependencies(),
-		msg.GetFullyQualifiedName(), fieldWraps)
+		msg.GetFullyQualifiedName(), false)
 }
 
 func (m *Model) buildServices(f *desc.FileDescriptor) []Service {
@@ -206,8 +387,9 @@ func (m *Model) buildServices(f *desc.FileDescriptor) []Service {
 				PyOutputMessageType: m.messageTypeRef(f, method.GetOutputType()),
 				PyInputMessageType:  m.messageTypeRef(f, method.GetInputType()),
 				PyName:              formatMethodName(method.GetName()),
+				ProtoName:           method.GetName(),
 				Route:               fmt.Sprintf("/%s/%s", s.GetFullyQualifiedName(), method.GetName()),
-				PyInputMessage:      formatClassName(method.GetInputType().GetName()),
+				PyInputMessage:      pyClassName(method.GetInputType()),
 				ServerStreaming:     method.IsServerStreaming(),
 				ClientStreaming:     method.IsClientStreaming(),
 			})
@@ -219,23 +401,37 @@ func (m *Model) buildServices(f *desc.FileDescriptor) []Service {
 			}
 		}
 		services = append(services, Service{
-			Comment: formatComment(s.GetSourceInfo().GetLeadingComments(), 1),
-			PyName:  formatClassName(s.GetName()),
-			Methods: methods,
+			Comment:  formatComment(s.GetSourceInfo().GetLeadingComments(), 1),
+			PyName:   formatClassName(s.GetName()),
+			FullName: s.GetFullyQualifiedName(),
+			Methods:  methods,
 		})
 	}
-	if len(services) > 0 {
+	if len(services) == 0 {
+		return services
+	}
+	if m.cfg.grpclib {
 		m.OutputFile.TypingImports = append(m.OutputFile.TypingImports, "Dict", "Optional")
 		m.OutputFile.ImportsTypeCheckingOnly = append(m.OutputFile.ImportsTypeCheckingOnly,
 			"from betterproto.grpc.grpclib_client import MetadataLike",
 			"from grpclib.metadata import Deadline",
 		)
+		if anyClientStreaming {
+			m.OutputFile.TypingImports = append(m.OutputFile.TypingImports, "AsyncIterable", "Iterable", "Union")
+		}
+		if anyClientStreaming || anyServerStreaming {
+			m.OutputFile.TypingImports = append(m.OutputFile.TypingImports, "AsyncIterator")
+		}
 	}
-	if anyClientStreaming {
-		m.OutputFile.TypingImports = append(m.OutputFile.TypingImports, "AsyncIterable", "Iterable", "Union")
-	}
-	if anyClientStreaming || anyServerStreaming {
-		m.OutputFile.TypingImports = append(m.OutputFile.TypingImports, "AsyncIterator")
+	if m.cfg.grpcio {
+		m.OutputFile.PythonModuleImports = append(m.OutputFile.PythonModuleImports, "grpc")
+		m.OutputFile.TypingImports = append(m.OutputFile.TypingImports, "Optional", "Sequence", "Tuple")
+		if anyClientStreaming {
+			m.OutputFile.TypingImports = append(m.OutputFile.TypingImports, "Iterable", "Iterator")
+		}
+		if anyServerStreaming {
+			m.OutputFile.TypingImports = append(m.OutputFile.TypingImports, "Iterator")
+		}
 	}
 	return services
 }
@@ -297,7 +493,7 @@ func (m *Model) pyType(field *desc.FieldDescriptor) (string, error) {
 			field.GetFile().GetDependencies(),
 			field.GetEnumType().GetFullyQualifiedName(), false), nil
 	}
-	return "", fmt.Errorf("unknown/unsupported type %s", field.GetType())
+	return "", fmt.Errorf("unsupported type %s", strings.TrimPrefix(field.GetType().String(), "TYPE_"))
 }
 
 func (o *OutputFile) cleanImports() {
@@ -0,0 +1,48 @@
+package python
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Versions of the runtime dependencies pinned in the generated pyproject.toml.
+const (
+	betterprotoVersion = "2.0.0b7"
+	grpclibVersion     = "0.4.7"
+	grpcioVersion      = "1.59.0"
+	pydanticVersion    = "2.4.2"
+)
+
+// pyproject returns a pyproject.toml for a distribution package containing the
+// python package pkg, with its runtime dependencies pinned.
+func pyproject(cfg config, pkg string) []byte {
+	deps := []string{"betterproto==" + betterprotoVersion}
+	if cfg.grpclib {
+		deps = append(deps, "grpclib=="+grpclibVersion)
+	}
+	if cfg.grpcio {
+		deps = append(deps, "grpcio=="+grpcioVersion)
+	}
+	if cfg.pydantic {
+		deps = append(deps, "pydantic=="+pydanticVersion)
+	}
+
+	buf := strings.Builder{}
+	buf.WriteString("[build-system]\n")
+	buf.WriteString("requires = [\"setuptools>=61\"]\n")
+	buf.WriteString("build-backend = \"setuptools.build_meta\"\n\n")
+	buf.WriteString("[project]\n")
+	fmt.Fprintf(&buf, "name = %q\n", cfg.packageName)
+	fmt.Fprintf(&buf, "version = %q\n", cfg.packageVersion)
+	buf.WriteString("requires-python = \">=3.8\"\n")
+	buf.WriteString("dependencies = [\n")
+	for _, dep := range deps {
+		fmt.Fprintf(&buf, "    %q,\n", dep)
+	}
+	buf.WriteString("]\n\n")
+	buf.WriteString("[tool.setuptools.packages.find]\n")
+	fmt.Fprintf(&buf, "include = [%q, %q]\n\n", pkg, pkg+".*")
+	buf.WriteString("[tool.setuptools.package-data]\n")
+	buf.WriteString("\"*\" = [\"py.typed\", \"*.pyi\"]\n")
+	return []byte(buf.String())
+}
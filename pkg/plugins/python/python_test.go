@@ -0,0 +1,569 @@
+package python_test
+
+import (
+	"crypto/sha256"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"runtime"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/kralicky/ragu"
+	"github.com/kralicky/ragu/pkg/plugins/python"
+	"golang.org/x/exp/maps"
+	"golang.org/x/exp/slices"
+)
+
+// The GROUP fixture is kept out of the top-level testdata directory, so that
+// its diagnostic is only reported here.
+func TestDiagnostics(t *testing.T) {
+	var diagnostics []python.Diagnostic
+	out, err := ragu.GenerateCode([]ragu.Generator{
+		python.NewGenerator(python.Options{
+			DiagnosticHook: func(d python.Diagnostic) {
+				diagnostics = append(diagnostics, d)
+			},
+		}),
+	}, "testdata/group/legacy.proto")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(diagnostics) != 1 {
+		t.Fatalf("expected 1 diagnostic, got %v", diagnostics)
+	}
+	if d := diagnostics[0]; d.Name != "group.Legacy2.details" || !strings.HasSuffix(d.Pos, "legacy.proto:8:3") {
+		t.Fatalf("unexpected diagnostic: %v", d)
+	}
+	for _, f := range out {
+		if f.Name != "legacy_pb.py" {
+			continue
+		}
+		for _, s := range []string{
+			"name: str = betterproto.string_field(1)",
+			"class Legacy2Details(betterproto.Message):",
+		} {
+			if !strings.Contains(f.Content, s) {
+				t.Fatalf("expected generated code to contain %q", s)
+			}
+		}
+		return
+	}
+	t.Fatal("legacy_pb.py was not generated")
+}
+
+// TestFields checks the generated oneof, optional, and deprecated fields, then
+// runs testdata/fields_test.py against them.
+func TestFields(t *testing.T) {
+	out, err := ragu.GenerateCode([]ragu.Generator{python.Generator}, "../../../testdata/pkg3/*.proto")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, f := range out {
+		if f.Name != "fields_pb.py" {
+			continue
+		}
+		for _, s := range []string{
+			`name: str = betterproto.string_field(1, group="choice")`,
+			`outer: Outer = betterproto.message_field(3, group="choice")`,
+			"nickname: Optional[str] = betterproto.string_field(4, optional=True)",
+			"kind: Optional[OuterKind] = betterproto.enum_field(5, optional=True)",
+			`warnings.warn("Fields.old_name is deprecated", DeprecationWarning)`,
+			`warnings.warn("Legacy is deprecated", DeprecationWarning)`,
+			"import warnings",
+		} {
+			if !strings.Contains(f.Content, s) {
+				t.Fatalf("expected generated code to contain %q", s)
+			}
+		}
+	}
+	runUnittest(t, out, "fields_test")
+}
+
+// TestNestedTypes checks that nested messages and enums are generated with
+// flattened names, including as map values and method types, then runs testdata/nested_test.py against them.
+func TestNestedTypes(t *testing.T) {
+	out, err := ragu.GenerateCode([]ragu.Generator{python.Generator}, "../../../testdata/pkg3/nested.proto")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, f := range out {
+		if f.Name != "nested_pb.py" {
+			continue
+		}
+		for _, s := range []string{
+			"class OuterKind(betterproto.Enum):",
+			"class OuterInnerDeepLevel(betterproto.Enum):",
+			"class OuterInner(betterproto.Message):",
+			"class OuterInnerDeep(betterproto.Message):",
+			"inners: Dict[str, OuterInner] = betterproto.map_field(4, betterproto.TYPE_STRING, betterproto.TYPE_MESSAGE)",
+			"async def descend(self, inner: OuterInner) -> OuterInnerDeep:",
+		} {
+			if !strings.Contains(f.Content, s) {
+				t.Fatalf("expected generated code to contain %q", s)
+			}
+		}
+		if strings.Contains(f.Content, "InnersEntry") {
+			t.Fatal("expected map entry messages to be omitted")
+		}
+	}
+	runUnittest(t, out, "nested_test")
+}
+
+// TestWellKnownFields checks that well-known type fields keep their wire types
+// and are exposed as plain values by generated properties, then runs
+// testdata/wkt_test.py against the generated code.
+func TestWellKnownFields(t *testing.T) {
+	out, err := ragu.GenerateCode([]ragu.Generator{
+		python.NewGenerator(python.Options{Opt: "stubs"}),
+	}, "../../../testdata/pkg3/wkt.proto")
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := map[string][]string{
+		"wkt_pb.py": {
+			`@ragu_wkt.register("pkg3.WellKnown")`,
+			"attributes: betterproto_lib_google_protobuf.Struct = betterproto.message_field(1)",
+			"value: betterproto_lib_google_protobuf.Value = betterproto.message_field(2)",
+			"detail: betterproto_lib_google_protobuf.Any = betterproto.message_field(5)",
+			"ratio: Optional[float] = betterproto.message_field(9, wraps=betterproto.TYPE_DOUBLE)",
+			"items: List[betterproto_lib_google_protobuf.Struct] = betterproto.message_field(10)",
+			"    def attributes_dict(self) -> Dict[str, Any]:\n" +
+				`        """ attributes as a plain value. """` + "\n" +
+				"        return ragu_wkt.from_struct(self.attributes)\n",
+			"    @attributes_dict.setter\n" +
+				"    def attributes_dict(self, value: Dict[str, Any]) -> None:\n" +
+				"        self.attributes = ragu_wkt.to_struct(value)\n",
+			"def value_value(self) -> Any:",
+			"def list_list(self) -> List[Any]:",
+			"self.mask = ragu_wkt.to_field_mask(value)",
+		},
+		"wkt_pb.pyi": {
+			"def attributes_dict(self) -> Dict[str, Any]: ...",
+			"def mask_paths(self, value: List[str]) -> None: ...",
+		},
+		"ragu_wkt.py": {
+			"def unpack(any: google_protobuf.Any) -> betterproto.Message:",
+			"def from_list_value(value: google_protobuf.ListValue) -> List[Any]:",
+		},
+	}
+	for _, f := range out {
+		for _, s := range expected[f.Name] {
+			if !strings.Contains(f.Content, s) {
+				t.Fatalf("expected %s to contain %q", f.Name, s)
+			}
+		}
+		if f.Name == "wkt_pb.py" && strings.Contains(f.Content, "def items_") {
+			t.Fatal("expected repeated fields to be generated without properties")
+		}
+		delete(expected, f.Name)
+	}
+	if len(expected) > 0 {
+		t.Fatalf("expected files were not generated: %v", maps.Keys(expected))
+	}
+	runUnittest(t, out, "wkt_test")
+}
+
+// TestWellKnownPropertyConflict checks that a property which would have the
+// same name as a field is omitted and reported.
+func TestWellKnownPropertyConflict(t *testing.T) {
+	var diagnostics []python.Diagnostic
+	out, err := ragu.GenerateCode([]ragu.Generator{
+		python.NewGenerator(python.Options{
+			DiagnosticHook: func(d python.Diagnostic) {
+				diagnostics = append(diagnostics, d)
+			},
+		}),
+	}, "testdata/conflict/conflict.proto")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(diagnostics) != 1 {
+		t.Fatalf("expected 1 diagnostic, got %v", diagnostics)
+	}
+	if d := diagnostics[0]; d.Name != "conflict.Conflict.attributes" || d.Err.Error() != "property attributes_dict is omitted, since it has the same name as a field" {
+		t.Fatalf("unexpected diagnostic: %v", d)
+	}
+	for _, f := range out {
+		if f.Name != "conflict_pb.py" {
+			continue
+		}
+		if strings.Contains(f.Content, "def attributes_dict") || !strings.Contains(f.Content, "def labels_dict") {
+			t.Fatalf("unexpected properties:\n%s", f.Content)
+		}
+		return
+	}
+	t.Fatal("conflict_pb.py was not generated")
+}
+
+// runUnittest writes the generated files and testdata/<module>.py into a
+// temporary directory, then runs the python tests in the module.
+func runUnittest(t *testing.T, out []*ragu.GeneratedFile, module string) {
+	t.Helper()
+	py := interpreter(t)
+	dir := t.TempDir()
+	for _, f := range out {
+		if err := os.WriteFile(filepath.Join(dir, f.Name), []byte(f.Content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	runModule(t, py, dir, module)
+}
+
+// runPackageUnittest writes the generated files into a temporary directory at
+// their paths relative to the source root, then runs the python tests in
+// testdata/<module>.py from the root directory, which contains the generated
+// python package.
+func runPackageUnittest(t *testing.T, out []*ragu.GeneratedFile, root, module string) {
+	t.Helper()
+	py := interpreter(t)
+	dir := t.TempDir()
+	for _, f := range out {
+		filename := filepath.Join(dir, filepath.FromSlash(f.SourceRelPath))
+		if err := os.MkdirAll(filepath.Dir(filename), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(filename, []byte(f.Content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	runModule(t, py, filepath.Join(dir, root), module)
+}
+
+func runModule(t *testing.T, py, dir, module string) {
+	t.Helper()
+	test, err := os.ReadFile(filepath.Join("testdata", module+".py"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, module+".py"), test, 0o644); err != nil {
+		t.Fatal(err)
+	}
+	cmd := exec.Command(py, "-m", "unittest", "-v", module)
+	cmd.Dir = dir
+	if output, err := cmd.CombinedOutput(); err != nil {
+		t.Fatalf("%v\n%s", err, output)
+	}
+}
+
+var (
+	venvOnce   sync.Once
+	venvPython string
+	venvErr    error
+)
+
+// interpreter returns the python interpreter of a virtual environment with
+// python.RuntimeRequirements installed. The environment is created once, in
+// the user cache directory. If it cannot be created, the test fails when
+// running in CI (with CI set in the environment), and is skipped otherwise.
+func interpreter(t *testing.T) string {
+	t.Helper()
+	if testing.Short() {
+		t.Skip("skipping python tests in short mode")
+	}
+	venvOnce.Do(func() {
+		venvPython, venvErr = createVenv(python.RuntimeRequirements)
+	})
+	if venvErr != nil {
+		if os.Getenv("CI") != "" {
+			t.Fatal(venvErr)
+		}
+		t.Skip(venvErr)
+	}
+	return venvPython
+}
+
+// createVenv creates a virtual environment with the given requirements
+// installed, or reuses one created with the same requirements.
+func createVenv(requirements []string) (string, error) {
+	cache, err := os.UserCacheDir()
+	if err != nil {
+		return "", err
+	}
+	sum := sha256.Sum256([]byte(strings.Join(requirements, "\n")))
+	dir := filepath.Join(cache, "ragu", fmt.Sprintf("python-%x", sum[:6]))
+	py := filepath.Join(dir, "bin", "python")
+	if runtime.GOOS == "windows" {
+		py = filepath.Join(dir, "Scripts", "python.exe")
+	}
+	// the marker is written last, so that a partially installed environment
+	// is recreated
+	marker := filepath.Join(dir, "ragu-installed")
+	if _, err := os.Stat(marker); err == nil {
+		return py, nil
+	}
+	if err := os.RemoveAll(dir); err != nil {
+		return "", err
+	}
+	if output, err := exec.Command("python3", "-m", "venv", dir).CombinedOutput(); err != nil {
+		return "", fmt.Errorf("creating a virtual environment: %v\n%s", err, output)
+	}
+	args := append([]string{"-m", "pip", "install", "--disable-pip-version-check", "--quiet"}, requirements...)
+	if output, err := exec.Command(py, args...).CombinedOutput(); err != nil {
+		return "", fmt.Errorf("installing %s: %v\n%s", strings.Join(requirements, " "), err, output)
+	}
+	if err := os.WriteFile(marker, nil, 0o644); err != nil {
+		return "", err
+	}
+	return py, nil
+}
+
+// TestPackageLayout checks that the types in the package layout reference each
+// other with relative imports, then runs testdata/layout_test.py against the
+// generated package.
+func TestPackageLayout(t *testing.T) {
+	out, err := ragu.GenerateCode([]ragu.Generator{
+		python.NewGenerator(python.Options{Opt: "layout=package,output_dir=python/layouttest"}),
+	}, "../../../testdata/pkg1/*.proto", "../../../testdata/pkg2/*.proto")
+	if err != nil {
+		t.Fatal(err)
+	}
+	files := map[string]string{}
+	for _, f := range out {
+		files[f.SourceRelPath] = f.Content
+	}
+	for name, contents := range map[string][]string{
+		"python/layouttest/pkg1/__init__.py": {
+			"from .. import ragu_wkt",
+			"class Test1(betterproto.Message):",
+			"class Test2(betterproto.Message):",
+		},
+		"python/layouttest/pkg2/__init__.py": {
+			"from .. import pkg1 as _pkg_1__",
+			"a: _pkg_1__.Test1 = betterproto.message_field(1)",
+		},
+		"python/layouttest/__init__.py": nil,
+		"python/layouttest/ragu_wkt.py": nil,
+	} {
+		content, ok := files[name]
+		if !ok {
+			t.Fatalf("%s was not generated", name)
+		}
+		for _, s := range contents {
+			if !strings.Contains(content, s) {
+				t.Fatalf("expected %s to contain %q", name, s)
+			}
+		}
+	}
+	runPackageUnittest(t, out, "python", "layout_test")
+}
+
+// TestGrpcio checks that only the grpcio stubs are generated when grpclib is
+// disabled, then runs testdata/grpcio_test.py against them.
+func TestGrpcio(t *testing.T) {
+	out, err := ragu.GenerateCode([]ragu.Generator{
+		python.NewGenerator(python.Options{Opt: "grpcio,grpclib=false"}),
+	}, "../../../testdata/grpc1/*.proto")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var content string
+	for _, f := range out {
+		if f.Name == "grpc_1_pb.py" {
+			content = f.Content
+		}
+	}
+	for _, s := range []string{
+		"import grpc\n",
+		"class Service1SyncStub:",
+		"class Service1Servicer:",
+		"def add_Service1Servicer_to_server(servicer: Service1Servicer, server: grpc.Server) -> None:",
+		`grpc.method_handlers_generic_handler("grpc1.Service1", rpc_method_handlers)`,
+	} {
+		if !strings.Contains(content, s) {
+			t.Fatalf("expected output to contain %q", s)
+		}
+	}
+	for _, s := range []string{"grpclib", "class Service1Stub(", "class Service1Base("} {
+		if strings.Contains(content, s) {
+			t.Fatalf("expected output not to contain %q", s)
+		}
+	}
+	runUnittest(t, out, "grpcio_test")
+}
+
+// TestPydantic checks the code generated with pydantic dataclasses, then runs
+// testdata/pydantic_test.py against it, with the dependencies pinned in the
+// generated pyproject.toml installed.
+func TestPydantic(t *testing.T) {
+	out, err := ragu.GenerateCode([]ragu.Generator{
+		python.NewGenerator(python.Options{
+			Opt: "layout=package,output_dir=python/pkgtest,package_name=pkgtest,pydantic_dataclasses",
+		}),
+	}, "../../../testdata/pkg3/*.proto")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var pins [][]string
+	for _, f := range out {
+		switch f.Name {
+		case "pyproject.toml":
+			pins = regexp.MustCompile(`"([\w-]+==[^"]+)"`).FindAllStringSubmatch(f.Content, -1)
+		case "__init__.py":
+			if f.SourceRelPath != "python/pkgtest/pkg3/__init__.py" {
+				continue
+			}
+			for _, s := range []string{
+				"    from pydantic.dataclasses import dataclass",
+				"from pydantic import model_validator",
+				`@dataclass(eq=False, repr=False, config={"extra": "forbid"})`,
+				"    @model_validator(mode=\"after\")\n    def check_oneof(cls, values):",
+				"rebuild_dataclass(Fields)  # type: ignore",
+				"attributes: betterproto_lib_google_protobuf.Struct = betterproto.message_field(1)",
+				"def attributes_dict(self) -> Dict[str, Any]:",
+			} {
+				if !strings.Contains(f.Content, s) {
+					t.Fatalf("expected %s to contain %q", f.SourceRelPath, s)
+				}
+			}
+		}
+	}
+	if len(pins) == 0 {
+		t.Fatal("pyproject.toml does not pin any dependencies")
+	}
+	for _, pin := range pins {
+		if !slices.Contains(python.RuntimeRequirements, pin[1]) {
+			t.Fatalf("%s is not one of the tested requirements %v", pin[1], python.RuntimeRequirements)
+		}
+	}
+	runPackageUnittest(t, out, "python", "pydantic_test")
+}
+
+// TestPackaging checks the distribution package files generated with
+// package_name, which pin only the enabled runtime dependencies.
+func TestPackaging(t *testing.T) {
+	out, err := ragu.GenerateCode([]ragu.Generator{
+		python.NewGenerator(python.Options{
+			Opt: "layout=package,output_dir=python/mylib,stubs,package_name=mylib,package_version=1.2.3",
+		}),
+	}, "../../../testdata/pkg1/*.proto", "../../../testdata/pkg2/*.proto")
+	if err != nil {
+		t.Fatal(err)
+	}
+	files := map[string]string{}
+	for _, f := range out {
+		files[f.SourceRelPath] = f.Content
+	}
+	for name, contents := range map[string][]string{
+		"python/pyproject.toml": {
+			`name = "mylib"`,
+			`version = "1.2.3"`,
+			"dependencies = [\n" +
+				`    "` + python.RuntimeRequirements[0] + `",` + "\n" +
+				`    "` + python.RuntimeRequirements[1] + `",` + "\n" +
+				"]\n",
+			`include = ["mylib", "mylib.*"]`,
+		},
+		"python/mylib/py.typed": nil,
+		"python/mylib/pkg1/__init__.pyi": {
+			"class Test1(betterproto.Message):",
+		},
+		"python/mylib/pkg2/__init__.pyi": {
+			"a: _pkg_1__.Test1 = ...",
+		},
+	} {
+		content, ok := files[name]
+		if !ok {
+			t.Fatalf("%s was not generated", name)
+		}
+		for _, s := range contents {
+			if !strings.Contains(content, s) {
+				t.Fatalf("expected %s to contain %q", name, s)
+			}
+		}
+	}
+
+	_, err = ragu.GenerateCode([]ragu.Generator{
+		python.NewGenerator(python.Options{PackageName: "mylib"}),
+	}, "../../../testdata/pkg1/*.proto")
+	if err == nil {
+		t.Fatal("expected an error for package_name without layout=package")
+	}
+}
+
+// TestTemplate checks that a custom template can extend the default template
+// and override its blocks.
+func TestTemplate(t *testing.T) {
+	out, err := ragu.GenerateCode([]ragu.Generator{
+		python.NewGenerator(python.Options{
+			Template: `{% extends "template.py.j2" %}
+{% block header %}# Copyright Example
+{% endblock %}
+{% block message_decorators %}@trace
+{% endblock %}
+{% block message_bases %}Base, {{ block.Super }}{% endblock %}
+{% block footer %}
+# {{ Model.OutputFile.InputFilenames|join:", " }}
+{% endblock %}`,
+		}),
+	}, "../../../testdata/pkg1/*.proto")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var content string
+	for _, f := range out {
+		if f.Name == "test_1_pb.py" {
+			content = f.Content
+		}
+	}
+	for _, s := range []string{
+		"# Copyright Example\n\nfrom __future__ import annotations",
+		"@trace\n@ragu_wkt.register(\"pkg1.Test1\")",
+		"class Test1(Base, betterproto.Message):",
+		"a: str = betterproto.string_field(1)",
+		"# github.com/kralicky/ragu/testdata/pkg1/test_1.proto",
+	} {
+		if !strings.Contains(content, s) {
+			t.Fatalf("expected output to contain %q", s)
+		}
+	}
+
+	_, err = ragu.GenerateCode([]ragu.Generator{
+		python.NewGenerator(python.Options{Template: `{% extends "missing.j2" %}`}),
+	}, "../../../testdata/pkg1/*.proto")
+	if err == nil {
+		t.Fatal("expected an error for an invalid template")
+	}
+}
+
+// TestDeterministic checks that modules rendered in parallel are written in a
+// stable order, with the same content.
+func TestDeterministic(t *testing.T) {
+	for _, opt := range []string{
+		"stubs,grpclib",
+		"layout=package,output_dir=python/pkgtest,stubs,grpclib,package_name=pkgtest",
+	} {
+		t.Run(opt, func(t *testing.T) {
+			var first []*ragu.GeneratedFile
+			for i := 0; i < 5; i++ {
+				out, err := ragu.GenerateCode([]ragu.Generator{
+					python.NewGenerator(python.Options{Opt: opt}),
+				}, "../../../testdata/pkg1/*.proto", "../../../testdata/pkg2/*.proto", "../../../testdata/pkg3/*.proto")
+				if err != nil {
+					t.Fatal(err)
+				}
+				if i == 0 {
+					if len(out) < 6 {
+						t.Fatalf("expected multiple modules, got %d files", len(out))
+					}
+					first = out
+					continue
+				}
+				if len(out) != len(first) {
+					t.Fatalf("run %d generated %d files, expected %d", i, len(out), len(first))
+				}
+				for j, f := range out {
+					if f.SourceRelPath != first[j].SourceRelPath {
+						t.Fatalf("run %d generated %s at index %d, expected %s", i, f.SourceRelPath, j, first[j].SourceRelPath)
+					}
+					if f.Content != first[j].Content {
+						t.Fatalf("run %d generated different content for %s", i, f.SourceRelPath)
+					}
+				}
+			}
+		})
+	}
+}
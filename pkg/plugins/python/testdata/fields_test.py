@@ -0,0 +1,45 @@
+"""
+Tests for oneof, optional, and deprecated fields, run by TestFields against the
+code generated from testdata/pkg3/*.proto.
+"""
+import unittest
+import warnings
+
+import betterproto
+from fields_pb import Fields, Legacy
+from nested_pb import Outer, OuterKind
+
+
+class FieldsTest(unittest.TestCase):
+    def test_oneof(self):
+        msg = Fields(name="a")
+        self.assertEqual(betterproto.which_one_of(msg, "choice"), ("name", "a"))
+        msg.id = 1
+        self.assertEqual(betterproto.which_one_of(msg, "choice"), ("id", 1))
+        parsed = Fields().parse(bytes(Fields(outer=Outer(kind=OuterKind.NESTED))))
+        name, outer = betterproto.which_one_of(parsed, "choice")
+        self.assertEqual(name, "outer")
+        self.assertEqual(outer.kind, OuterKind.NESTED)
+
+    def test_optional(self):
+        parsed = Fields().parse(bytes(Fields()))
+        self.assertIsNone(parsed.nickname)
+        self.assertIsNone(parsed.kind)
+        parsed = Fields().parse(bytes(Fields(nickname="", kind=OuterKind.UNKNOWN)))
+        self.assertEqual(parsed.nickname, "")
+        self.assertEqual(parsed.kind, OuterKind.UNKNOWN)
+
+    def test_deprecated(self):
+        with warnings.catch_warnings(record=True) as caught:
+            warnings.simplefilter("always")
+            Fields(name="a")
+            self.assertEqual(caught, [])
+            Fields(old_name="a")
+            Legacy(value="a")
+        messages = [str(w.message) for w in caught if issubclass(w.category, DeprecationWarning)]
+        self.assertIn("Fields.old_name is deprecated", messages)
+        self.assertIn("Legacy is deprecated", messages)
+
+
+if __name__ == "__main__":
+    unittest.main()
@@ -0,0 +1,43 @@
+"""
+Tests for the grpcio stubs, run by TestGrpcio against the code generated from
+testdata/grpc1/*.proto.
+"""
+import unittest
+from concurrent import futures
+
+import grpc
+from grpc_1_pb import Service1Servicer, Service1SyncStub, Test, add_Service1Servicer_to_server
+
+
+class Servicer(Service1Servicer):
+    def testing(self, request: Test, context: grpc.ServicerContext) -> Test:
+        return Test(a=request.a, b=request.b + 1)
+
+
+class GrpcioTest(unittest.TestCase):
+    def serve(self, servicer: Service1Servicer) -> Service1SyncStub:
+        server = grpc.server(futures.ThreadPoolExecutor(max_workers=2))
+        add_Service1Servicer_to_server(servicer, server)
+        port = server.add_insecure_port("127.0.0.1:0")
+        server.start()
+        self.addCleanup(server.stop, None)
+        channel = grpc.insecure_channel(f"127.0.0.1:{port}")
+        self.addCleanup(channel.close)
+        return Service1SyncStub(channel)
+
+    def test_unary(self):
+        stub = self.serve(Servicer())
+        response = stub.testing(Test(a="a", b=1), timeout=10)
+        self.assertIsInstance(response, Test)
+        self.assertEqual(response.a, "a")
+        self.assertEqual(response.b, 2)
+
+    def test_unimplemented(self):
+        stub = self.serve(Service1Servicer())
+        with self.assertRaises(grpc.RpcError) as cm:
+            stub.testing(Test(a="a"), timeout=10)
+        self.assertEqual(cm.exception.code(), grpc.StatusCode.UNIMPLEMENTED)
+
+
+if __name__ == "__main__":
+    unittest.main()
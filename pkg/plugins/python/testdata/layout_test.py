@@ -0,0 +1,29 @@
+"""
+Tests for the package layout, run by TestPackageLayout against the package
+generated from testdata/pkg1/*.proto and testdata/pkg2/*.proto.
+"""
+import unittest
+
+from layouttest import ragu_wkt
+from layouttest.pkg1 import Test1, Test2
+from layouttest.pkg2 import Test3
+
+
+class PackageLayoutTest(unittest.TestCase):
+    def test_round_trip(self):
+        msg = Test3(a=Test1(a="x", b=1), b=Test2())
+        parsed = Test3().parse(bytes(msg))
+        self.assertEqual(parsed.a.a, "x")
+        self.assertEqual(parsed.a.b, 1)
+
+    def test_registry(self):
+        # the packages share the support module in the root package
+        self.assertIs(ragu_wkt.message_type("pkg1.Test1"), Test1)
+        self.assertIs(ragu_wkt.message_type("pkg2.Test3"), Test3)
+        unpacked = ragu_wkt.unpack(ragu_wkt.pack(Test1(a="x")))
+        self.assertIsInstance(unpacked, Test1)
+        self.assertEqual(unpacked.a, "x")
+
+
+if __name__ == "__main__":
+    unittest.main()
@@ -0,0 +1,41 @@
+"""
+Tests for nested types, run by TestNestedTypes against the code generated from
+testdata/pkg3/nested.proto.
+"""
+import unittest
+
+from nested_pb import NestedBase, Outer, OuterInner, OuterInnerDeep, OuterInnerDeepLevel, OuterKind, Sibling
+
+
+class NestedTypesTest(unittest.TestCase):
+    def test_outer(self):
+        msg = Outer(
+            inner=OuterInner(deep=OuterInnerDeep(level=OuterInnerDeepLevel.HIGH)),
+            deep=OuterInnerDeep(level=OuterInnerDeepLevel.HIGH),
+            kind=OuterKind.NESTED,
+            inners={"a": OuterInner(parent=Outer(kind=OuterKind.NESTED))},
+        )
+        parsed = Outer().parse(bytes(msg))
+        self.assertEqual(parsed.inner.deep.level, OuterInnerDeepLevel.HIGH)
+        self.assertEqual(parsed.deep.level, OuterInnerDeepLevel.HIGH)
+        self.assertEqual(parsed.kind, OuterKind.NESTED)
+        self.assertEqual(parsed.inners["a"].parent.kind, OuterKind.NESTED)
+
+    def test_sibling(self):
+        msg = Sibling(
+            inner=OuterInner(deep=OuterInnerDeep(level=OuterInnerDeepLevel.HIGH)),
+            level=OuterInnerDeepLevel.HIGH,
+        )
+        parsed = Sibling().parse(bytes(msg))
+        self.assertEqual(parsed.inner.deep.level, OuterInnerDeepLevel.HIGH)
+        self.assertEqual(parsed.level, OuterInnerDeepLevel.HIGH)
+        self.assertEqual(parsed.to_dict(), {"inner": {"deep": {"level": "HIGH"}}, "level": "HIGH"})
+
+    def test_service(self):
+        handler = NestedBase().__mapping__()["/pkg3.Nested/Descend"]
+        self.assertIs(handler.request_type, OuterInner)
+        self.assertIs(handler.reply_type, OuterInnerDeep)
+
+
+if __name__ == "__main__":
+    unittest.main()
@@ -0,0 +1,41 @@
+import unittest
+
+import pydantic
+
+from pkgtest.pkg3 import Fields, Outer, OuterKind, WellKnown
+
+
+class PydanticTest(unittest.TestCase):
+    def test_construct(self):
+        msg = Fields(name="a", nickname="b", kind=OuterKind.NESTED)
+        self.assertEqual(msg.name, "a")
+        self.assertEqual(msg.nickname, "b")
+        self.assertEqual(msg.kind, OuterKind.NESTED)
+
+    def test_round_trip(self):
+        msg = Fields(outer=Outer(kind=OuterKind.NESTED), nickname="b")
+        parsed = Fields().parse(bytes(msg))
+        self.assertEqual(parsed.outer.kind, OuterKind.NESTED)
+        self.assertEqual(parsed.nickname, "b")
+        self.assertEqual(Fields().from_json(msg.to_json()).nickname, "b")
+
+    def test_oneof(self):
+        with self.assertRaises(pydantic.ValidationError):
+            Fields(name="a", id=1)
+
+    def test_extra_fields(self):
+        with self.assertRaises(pydantic.ValidationError):
+            Fields(unknown="a")
+
+    def test_field_types(self):
+        with self.assertRaises(pydantic.ValidationError):
+            Fields(outer="a")
+
+    def test_well_known_properties(self):
+        msg = WellKnown()
+        msg.attributes_dict = {"a": ["b", 1.0]}
+        self.assertEqual(WellKnown().parse(bytes(msg)).attributes_dict, {"a": ["b", 1.0]})
+
+
+if __name__ == "__main__":
+    unittest.main()
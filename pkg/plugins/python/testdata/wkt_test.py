@@ -0,0 +1,72 @@
+"""
+Tests for the well-known type properties and the ragu_wkt support module, run
+by TestWellKnownFields against the code generated from
+testdata/pkg3/wkt.proto.
+"""
+import unittest
+
+import betterproto.lib.google.protobuf as google_protobuf
+import ragu_wkt
+from wkt_pb import WellKnown
+
+
+def new_message() -> WellKnown:
+    msg = WellKnown()
+    msg.attributes_dict = {"a": 1.0, "b": [True, None, "x"], "c": {"d": "e"}}
+    msg.value_value = "v"
+    msg.list_list = [1.0, "two"]
+    msg.mask_paths = ["foo.bar_baz", "qux"]
+    return msg
+
+
+class WellKnownFieldsTest(unittest.TestCase):
+    def assert_plain(self, msg: WellKnown) -> None:
+        self.assertEqual(msg.attributes_dict, {"a": 1.0, "b": [True, None, "x"], "c": {"d": "e"}})
+        self.assertEqual(msg.value_value, "v")
+        self.assertEqual(msg.list_list, [1.0, "two"])
+        self.assertEqual(msg.mask_paths, ["foo.bar_baz", "qux"])
+
+    def test_defaults(self):
+        msg = WellKnown()
+        self.assertEqual(msg.attributes_dict, {})
+        self.assertIsNone(msg.value_value)
+        self.assertEqual(msg.list_list, [])
+        self.assertEqual(msg.mask_paths, [])
+        self.assertEqual(bytes(msg), b"")
+
+    def test_wire_types(self):
+        msg = new_message()
+        self.assertIsInstance(msg.attributes, google_protobuf.Struct)
+        self.assertEqual(msg.attributes.fields["c"].struct_value.fields["d"].string_value, "e")
+        self.assertIsInstance(msg.value, google_protobuf.Value)
+        self.assertEqual(msg.value.string_value, "v")
+        self.assertEqual(msg.list.values[1].string_value, "two")
+        self.assertEqual(msg.mask.paths, ["foo.bar_baz", "qux"])
+
+    def test_bytes(self):
+        msg = new_message()
+        data = bytes(msg)
+        self.assert_plain(WellKnown().parse(data))
+        self.assert_plain(WellKnown.FromString(data))
+
+    def test_dict(self):
+        msg = new_message()
+        self.assert_plain(WellKnown().from_dict(msg.to_dict()))
+
+    def test_json(self):
+        msg = new_message()
+        self.assert_plain(WellKnown().from_json(msg.to_json()))
+
+    def test_any(self):
+        msg = new_message()
+        self.assert_plain(ragu_wkt.unpack(ragu_wkt.pack(msg)))
+
+    def test_converters(self):
+        value = {"a": [1.0, {"b": None}], "c": False}
+        self.assertEqual(ragu_wkt.from_struct(ragu_wkt.to_struct(value)), value)
+        with self.assertRaises(TypeError):
+            ragu_wkt.to_value(object())
+
+
+if __name__ == "__main__":
+    unittest.main()
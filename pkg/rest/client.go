@@ -0,0 +1,284 @@
+// Package rest contains the runtime used by code generated by the
+// go-rest-client generator. Generated clients call gRPC services through the
+// HTTP endpoints served by grpc-gateway, using protojson encoding.
+package rest
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+
+	spb "google.golang.org/genproto/googleapis/rpc/status"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+)
+
+const (
+	metadataHeaderPrefix  = "Grpc-Metadata-"
+	metadataTrailerPrefix = "Grpc-Trailer-"
+)
+
+var unmarshalOptions = protojson.UnmarshalOptions{DiscardUnknown: true}
+
+// Client sends requests to a grpc-gateway server.
+type Client struct {
+	baseURL    string
+	httpClient *http.Client
+}
+
+// NewClient returns a client which sends requests to the server at baseURL
+// (e.g. "https://example.com/api"), using the given http.Client. If
+// httpClient is nil, http.DefaultClient is used.
+func NewClient(baseURL string, httpClient *http.Client) *Client {
+	if httpClient == nil {
+		httpClient = http.DefaultClient
+	}
+	return &Client{
+		baseURL:    strings.TrimSuffix(baseURL, "/"),
+		httpClient: httpClient,
+	}
+}
+
+// Binding describes a single google.api.http rule of a method.
+type Binding struct {
+	// HTTP method, e.g. "GET".
+	Method string
+	// Path template, e.g. "/v1/{name=shelves/*}/books".
+	Template string
+	// Body is empty if the request has no body, "*" if the entire request
+	// message is sent as the body, or the name of the request field sent as
+	// the body. Fields not bound to the path or body are sent as query
+	// parameters.
+	Body string
+	// ResponseBody is the name of the response field the response body is
+	// mapped to, or empty if the body is the entire response message.
+	ResponseBody string
+}
+
+// Invoke sends a unary request and unmarshals the response into out.
+//
+// Outgoing metadata in ctx is sent as Grpc-Metadata-* headers. The
+// grpc.Header and grpc.Trailer call options are supported; other call
+// options are ignored.
+func (c *Client) Invoke(ctx context.Context, b *Binding, in, out proto.Message, opts ...grpc.CallOption) error {
+	resp, err := c.do(ctx, b, in, opts)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return status.Error(codes.Unavailable, err.Error())
+	}
+	setTrailer(resp, opts)
+	if err := unmarshalResponse(b, body, out); err != nil {
+		return status.Errorf(codes.Internal, "failed to unmarshal response: %v", err)
+	}
+	return nil
+}
+
+func (c *Client) do(ctx context.Context, b *Binding, in proto.Message, opts []grpc.CallOption) (*http.Response, error) {
+	req, err := c.newRequest(ctx, b, in)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		if ctxErr := ctx.Err(); ctxErr != nil {
+			return nil, status.FromContextError(ctxErr).Err()
+		}
+		return nil, status.Error(codes.Unavailable, err.Error())
+	}
+	setHeader(resp, opts)
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		defer resp.Body.Close()
+		return nil, responseError(resp)
+	}
+	return resp, nil
+}
+
+func (c *Client) newRequest(ctx context.Context, b *Binding, in proto.Message) (*http.Request, error) {
+	path, pathParams, err := expandTemplate(b.Template, in.ProtoReflect())
+	if err != nil {
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
+	var body io.Reader
+	if b.Body != "" {
+		data, err := marshalBody(b.Body, in)
+		if err != nil {
+			return nil, status.Errorf(codes.Internal, "failed to marshal request: %v", err)
+		}
+		body = bytes.NewReader(data)
+	}
+	if b.Body != "*" {
+		exclude := map[string]bool{}
+		for _, p := range pathParams {
+			exclude[p] = true
+		}
+		if b.Body != "" {
+			exclude[b.Body] = true
+		}
+		query := url.Values{}
+		if err := queryParams(in.ProtoReflect(), "", exclude, query); err != nil {
+			return nil, status.Error(codes.InvalidArgument, err.Error())
+		}
+		if len(query) > 0 {
+			path += "?" + query.Encode()
+		}
+	}
+
+	req, err := http.NewRequestWithContext(ctx, b.Method, c.baseURL+path, body)
+	if err != nil {
+		return nil, status.Error(codes.Internal, err.Error())
+	}
+	req.Header.Set("Accept", "application/json")
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	if md, ok := metadata.FromOutgoingContext(ctx); ok {
+		for key, values := range md {
+			for _, value := range values {
+				if strings.HasSuffix(key, "-bin") {
+					value = base64.StdEncoding.EncodeToString([]byte(value))
+				}
+				req.Header.Add(metadataHeaderPrefix+key, value)
+			}
+		}
+	}
+	return req, nil
+}
+
+// marshalBody marshals the request body. If the body is a single field, only
+// the JSON value of that field is sent.
+func marshalBody(field string, in proto.Message) ([]byte, error) {
+	if field == "*" {
+		return protojson.Marshal(in)
+	}
+	m := in.ProtoReflect()
+	fd := m.Descriptor().Fields().ByTextName(field)
+	if fd == nil {
+		return nil, fmt.Errorf("no field %q in %s", field, m.Descriptor().FullName())
+	}
+	if fd.Message() != nil && !fd.IsList() && !fd.IsMap() {
+		return protojson.Marshal(m.Get(fd).Message().Interface())
+	}
+	// marshal a copy of the message containing only the field, and extract
+	// its value. Unpopulated values are only emitted for scalars, since
+	// nested messages would otherwise be sent with all of their fields set,
+	// which changes the update mask inferred by grpc-gateway for PATCH.
+	partial := m.Type().New()
+	if m.Has(fd) {
+		partial.Set(fd, m.Get(fd))
+	}
+	opts := protojson.MarshalOptions{EmitUnpopulated: !fd.IsList() && !fd.IsMap()}
+	data, err := opts.Marshal(partial.Interface())
+	if err != nil {
+		return nil, err
+	}
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(data, &fields); err != nil {
+		return nil, err
+	}
+	if value, ok := fields[fd.JSONName()]; ok {
+		return value, nil
+	}
+	if fd.IsMap() {
+		return []byte("{}"), nil
+	}
+	return []byte("[]"), nil
+}
+
+func unmarshalResponse(b *Binding, body []byte, out proto.Message) error {
+	if b.ResponseBody != "" {
+		// wrap the body so that it is unmarshaled into the response field
+		wrapped, err := json.Marshal(map[string]json.RawMessage{b.ResponseBody: body})
+		if err != nil {
+			return err
+		}
+		body = wrapped
+	}
+	return unmarshalOptions.Unmarshal(body, out)
+}
+
+// responseError converts an error response into a gRPC status error. The
+// body of error responses written by grpc-gateway is a google.rpc.Status.
+func responseError(resp *http.Response) error {
+	body, _ := io.ReadAll(resp.Body)
+	st := &spb.Status{}
+	if err := unmarshalOptions.Unmarshal(body, st); err == nil && st.GetCode() != 0 {
+		return status.ErrorProto(st)
+	}
+	// the details could not be resolved or the response was not written by
+	// grpc-gateway; fall back to the code and message only
+	var fallback struct {
+		Code    codes.Code `json:"code"`
+		Message string     `json:"message"`
+	}
+	if err := json.Unmarshal(body, &fallback); err == nil && fallback.Code != codes.OK {
+		return status.Error(fallback.Code, fallback.Message)
+	}
+	return status.Errorf(codeFromHTTPStatus(resp.StatusCode), "unexpected HTTP status %s", resp.Status)
+}
+
+// codeFromHTTPStatus is the inverse of runtime.HTTPStatusFromCode.
+func codeFromHTTPStatus(code int) codes.Code {
+	switch code {
+	case http.StatusBadRequest:
+		return codes.InvalidArgument
+	case http.StatusUnauthorized:
+		return codes.Unauthenticated
+	case http.StatusForbidden:
+		return codes.PermissionDenied
+	case http.StatusNotFound:
+		return codes.NotFound
+	case http.StatusConflict:
+		return codes.Aborted
+	case http.StatusTooManyRequests:
+		return codes.ResourceExhausted
+	case 499:
+		return codes.Canceled
+	case http.StatusNotImplemented:
+		return codes.Unimplemented
+	case http.StatusServiceUnavailable:
+		return codes.Unavailable
+	case http.StatusGatewayTimeout:
+		return codes.DeadlineExceeded
+	}
+	return codes.Unknown
+}
+
+func setHeader(resp *http.Response, opts []grpc.CallOption) {
+	for _, opt := range opts {
+		if o, ok := opt.(grpc.HeaderCallOption); ok {
+			*o.HeaderAddr = headerMetadata(resp.Header, metadataHeaderPrefix)
+		}
+	}
+}
+
+func setTrailer(resp *http.Response, opts []grpc.CallOption) {
+	for _, opt := range opts {
+		if o, ok := opt.(grpc.TrailerCallOption); ok {
+			*o.TrailerAddr = headerMetadata(resp.Trailer, metadataTrailerPrefix)
+		}
+	}
+}
+
+func headerMetadata(header http.Header, prefix string) metadata.MD {
+	md := metadata.MD{}
+	for key, values := range header {
+		if name, ok := strings.CutPrefix(key, prefix); ok {
+			md.Append(strings.ToLower(name), values...)
+		}
+	}
+	return md
+}
@@ -0,0 +1,144 @@
+package rest
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/kralicky/grpc-gateway/v2/runtime"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/encoding/protojson"
+)
+
+func TestMarshalBody(t *testing.T) {
+	m := newItem(t, `{"name": "a", "labels": {"k": 1}, "parent": {"name": "p"}}`)
+	for _, tc := range []struct {
+		field, body string
+	}{
+		{"*", `{"name":"a","labels":{"k":1},"parent":{"name":"p"}}`},
+		{"parent", `{"name":"p"}`},
+		{"name", `"a"`},
+		// unpopulated scalars are sent, so that they can be used in updates
+		{"count", `0`},
+		{"labels", `{"k":1}`},
+		{"tags", `[]`},
+	} {
+		data, err := marshalBody(tc.field, m)
+		if err != nil {
+			t.Errorf("%s: %v", tc.field, err)
+			continue
+		}
+		if compact := strings.ReplaceAll(string(data), " ", ""); compact != tc.body {
+			t.Errorf("%s: expected %s, got %s", tc.field, tc.body, compact)
+		}
+	}
+	if _, err := marshalBody("missing", m); err == nil {
+		t.Error("expected an error for an unknown field")
+	}
+}
+
+func TestResponseError(t *testing.T) {
+	for _, tc := range []struct {
+		name    string
+		status  int
+		body    string
+		code    codes.Code
+		message string
+	}{
+		{"status", http.StatusNotFound, `{"code": 5, "message": "no such item", "details": []}`, codes.NotFound, "no such item"},
+		{"unresolvable details", http.StatusBadRequest, `{"code": 3, "message": "bad", "details": [{"@type": "type.googleapis.com/unknown.Detail"}]}`, codes.InvalidArgument, "bad"},
+		{"not json", http.StatusServiceUnavailable, `upstream unavailable`, codes.Unavailable, "unexpected HTTP status 503 Service Unavailable"},
+		{"unknown status", http.StatusTeapot, ``, codes.Unknown, "unexpected HTTP status 418 I'm a teapot"},
+	} {
+		srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(tc.status)
+			io.WriteString(w, tc.body)
+		}))
+		resp, err := http.Get(srv.URL)
+		if err != nil {
+			t.Fatal(err)
+		}
+		err = responseError(resp)
+		resp.Body.Close()
+		srv.Close()
+		if st := status.Convert(err); st.Code() != tc.code || st.Message() != tc.message {
+			t.Errorf("%s: expected %v %q, got %v", tc.name, tc.code, tc.message, err)
+		}
+	}
+}
+
+func TestInvoke(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPatch || r.URL.EscapedPath() != "/v1/items/a" || r.URL.RawQuery != "count=2" {
+			t.Errorf("unexpected request: %s %s", r.Method, r.URL)
+		}
+		if r.Header.Get("Grpc-Metadata-Key") != "value" || r.Header.Get("Content-Type") != "application/json" {
+			t.Errorf("unexpected headers: %v", r.Header)
+		}
+		body, _ := io.ReadAll(r.Body)
+		if string(body) != `{"name":"p"}` {
+			t.Errorf("unexpected body: %s", body)
+		}
+		w.Header().Set("Grpc-Metadata-Header", "h")
+		w.Header().Set("Trailer", "Grpc-Trailer-Trailer")
+		io.WriteString(w, `{"name": "b", "unknown": true}`)
+		w.Header().Set("Grpc-Trailer-Trailer", "t")
+	}))
+	defer srv.Close()
+
+	c := NewClient(srv.URL+"/", nil)
+	ctx := metadata.AppendToOutgoingContext(context.Background(), "key", "value")
+	in := newItem(t, `{"name": "a", "count": 2, "parent": {"name": "p"}}`)
+	out := newItem(t, `{}`)
+	var header, trailer metadata.MD
+	err := c.Invoke(ctx, &Binding{Method: "PATCH", Template: "/v1/items/{name}", Body: "parent", ResponseBody: "parent"}, in, out,
+		grpc.Header(&header), grpc.Trailer(&trailer))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if data, _ := protojson.Marshal(out); strings.ReplaceAll(string(data), " ", "") != `{"parent":{"name":"b"}}` {
+		t.Errorf("unexpected response: %s", data)
+	}
+	if header.Get("header")[0] != "h" || trailer.Get("trailer")[0] != "t" {
+		t.Errorf("unexpected metadata: %v, %v", header, trailer)
+	}
+}
+
+// Single-segment path variables are escaped, including slashes. The default
+// (legacy) unescaping mode of the grpc-gateway mux unescapes the path before
+// routing, so such requests do not match the route.
+func TestInvokeEscaping(t *testing.T) {
+	for _, tc := range []struct {
+		name string
+		opts []runtime.ServeMuxOption
+		code codes.Code
+	}{
+		{"legacy", nil, codes.NotFound},
+		{"all except reserved", []runtime.ServeMuxOption{runtime.WithUnescapingMode(runtime.UnescapingModeAllExceptReserved)}, codes.OK},
+	} {
+		mux := runtime.NewServeMux(tc.opts...)
+		err := mux.HandlePath("GET", "/v1/items/{name}", func(w http.ResponseWriter, r *http.Request, pathParams map[string]string) {
+			io.WriteString(w, `{"name": "`+pathParams["name"]+`"}`)
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		srv := httptest.NewServer(mux)
+		out := newItem(t, `{}`)
+		err = NewClient(srv.URL, srv.Client()).Invoke(context.Background(), &Binding{Method: "GET", Template: "/v1/items/{name}"}, newItem(t, `{"name": "a b/c"}`), out)
+		srv.Close()
+		if status.Code(err) != tc.code {
+			t.Errorf("%s: expected %v, got %v", tc.name, tc.code, err)
+			continue
+		}
+		if name := out.Get(out.Descriptor().Fields().ByName("name")).String(); err == nil && name != "a b/c" {
+			t.Errorf("%s: expected the path parameter to be %q, got %q", tc.name, "a b/c", name)
+		}
+	}
+}
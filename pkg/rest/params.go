@@ -0,0 +1,159 @@
+package rest
+
+import (
+	"encoding/base64"
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/reflect/protoreflect"
+)
+
+// expandTemplate replaces the variables in a path template with the values
+// of the corresponding fields in the message. It returns the expanded path
+// and the field paths of the variables.
+//
+// Values of single-segment variables are escaped as a single path segment, so
+// slashes are sent as %2F. The grpc-gateway mux only routes these requests in
+// the UnescapingModeAllExceptReserved and UnescapingModeAllExceptSlash modes;
+// the default UnescapingModeLegacy unescapes the path before matching it.
+func expandTemplate(template string, m protoreflect.Message) (string, []string, error) {
+	var sb strings.Builder
+	var fieldPaths []string
+	for rest := template; rest != ""; {
+		start := strings.IndexByte(rest, '{')
+		if start == -1 {
+			sb.WriteString(rest)
+			break
+		}
+		end := strings.IndexByte(rest[start:], '}')
+		if end == -1 {
+			return "", nil, fmt.Errorf("invalid path template %q", template)
+		}
+		end += start
+		sb.WriteString(rest[:start])
+		fieldPath, pattern, _ := strings.Cut(rest[start+1:end], "=")
+		value, err := pathValue(m, fieldPath)
+		if err != nil {
+			return "", nil, err
+		}
+		if value == "" {
+			return "", nil, fmt.Errorf("path parameter %q is empty", fieldPath)
+		}
+		if strings.Contains(pattern, "/") || strings.Contains(pattern, "**") {
+			// multi-segment variables keep their slashes
+			segments := strings.Split(value, "/")
+			for i, s := range segments {
+				segments[i] = url.PathEscape(s)
+			}
+			sb.WriteString(strings.Join(segments, "/"))
+		} else {
+			sb.WriteString(url.PathEscape(value))
+		}
+		fieldPaths = append(fieldPaths, fieldPath)
+		rest = rest[end+1:]
+	}
+	return sb.String(), fieldPaths, nil
+}
+
+// pathValue returns the formatted value of the field at the given
+// dot-separated path.
+func pathValue(m protoreflect.Message, fieldPath string) (string, error) {
+	names := strings.Split(fieldPath, ".")
+	for i, name := range names {
+		fd := m.Descriptor().Fields().ByTextName(name)
+		if fd == nil {
+			return "", fmt.Errorf("no field %q in %s", name, m.Descriptor().FullName())
+		}
+		if i == len(names)-1 {
+			if fd.IsList() {
+				list := m.Get(fd).List()
+				values := make([]string, list.Len())
+				for j := range values {
+					v, err := formatValue(fd, list.Get(j))
+					if err != nil {
+						return "", err
+					}
+					values[j] = v
+				}
+				return strings.Join(values, ","), nil
+			}
+			return formatValue(fd, m.Get(fd))
+		}
+		if fd.Message() == nil || fd.IsList() || fd.IsMap() {
+			return "", fmt.Errorf("field %q in %s is not a singular message field", name, m.Descriptor().FullName())
+		}
+		m = m.Get(fd).Message()
+	}
+	return "", nil
+}
+
+// queryParams adds the populated fields of the message to the query,
+// excluding the given field paths. Nested messages are flattened into
+// dot-separated parameter names, as expected by grpc-gateway.
+func queryParams(m protoreflect.Message, prefix string, exclude map[string]bool, query url.Values) (err error) {
+	m.Range(func(fd protoreflect.FieldDescriptor, v protoreflect.Value) bool {
+		name := prefix + fd.TextName()
+		if exclude[name] {
+			return true
+		}
+		switch {
+		case fd.IsMap():
+			v.Map().Range(func(key protoreflect.MapKey, value protoreflect.Value) bool {
+				var s string
+				s, err = formatValue(fd.MapValue(), value)
+				query.Add(name+"["+key.String()+"]", s)
+				return err == nil
+			})
+		case fd.IsList():
+			list := v.List()
+			for i := 0; i < list.Len() && err == nil; i++ {
+				var s string
+				s, err = formatValue(fd, list.Get(i))
+				query.Add(name, s)
+			}
+		case fd.Message() != nil && !isWellKnownType(fd.Message()):
+			err = queryParams(v.Message(), name+".", exclude, query)
+		default:
+			var s string
+			s, err = formatValue(fd, v)
+			query.Add(name, s)
+		}
+		return err == nil
+	})
+	return err
+}
+
+// formatValue formats a single (non-list) field value as a path or query
+// parameter.
+func formatValue(fd protoreflect.FieldDescriptor, v protoreflect.Value) (string, error) {
+	switch fd.Kind() {
+	case protoreflect.EnumKind:
+		if ev := fd.Enum().Values().ByNumber(v.Enum()); ev != nil {
+			return string(ev.Name()), nil
+		}
+		return strconv.Itoa(int(v.Enum())), nil
+	case protoreflect.BytesKind:
+		return base64.URLEncoding.EncodeToString(v.Bytes()), nil
+	case protoreflect.MessageKind, protoreflect.GroupKind:
+		if !isWellKnownType(fd.Message()) {
+			return "", fmt.Errorf("message field %q cannot be used as a parameter", fd.FullName())
+		}
+		data, err := protojson.Marshal(v.Message().Interface())
+		if err != nil {
+			return "", err
+		}
+		if s, err := strconv.Unquote(string(data)); err == nil {
+			return s, nil
+		}
+		return string(data), nil
+	default:
+		return v.String(), nil
+	}
+}
+
+func isWellKnownType(md protoreflect.MessageDescriptor) bool {
+	return md.ParentFile().Package() == "google.protobuf"
+}
@@ -0,0 +1,102 @@
+package rest
+
+import (
+	"context"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/bufbuild/protocompile"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/types/dynamicpb"
+)
+
+// newItem returns a rest.test.Item from testdata/rest.proto, populated from
+// its JSON representation.
+func newItem(t *testing.T, json string) *dynamicpb.Message {
+	t.Helper()
+	compiler := protocompile.Compiler{
+		Resolver: protocompile.WithStandardImports(&protocompile.SourceResolver{ImportPaths: []string{"testdata"}}),
+	}
+	files, err := compiler.Compile(context.Background(), "rest.proto")
+	if err != nil {
+		t.Fatal(err)
+	}
+	m := dynamicpb.NewMessage(files[0].Messages().ByName("Item"))
+	if err := protojson.Unmarshal([]byte(json), m); err != nil {
+		t.Fatal(err)
+	}
+	return m
+}
+
+func TestExpandTemplate(t *testing.T) {
+	for _, tc := range []struct {
+		template, item string
+		path           string
+		fieldPaths     []string
+	}{
+		{"/v1/items", `{"name": "a"}`, "/v1/items", nil},
+		{"/v1/items/{name}", `{"name": "a b"}`, "/v1/items/a%20b", []string{"name"}},
+		// single-segment variables escape slashes
+		{"/v1/items/{name}", `{"name": "a/b"}`, "/v1/items/a%2Fb", []string{"name"}},
+		{"/v1/{path=items/**}", `{"path": "items/a b/c"}`, "/v1/items/a%20b/c", []string{"path"}},
+		{"/v1/{parent.name}/items/{name=*}:get", `{"name": "b", "parent": {"name": "a"}}`, "/v1/a/items/b:get", []string{"parent.name", "name"}},
+		{"/v1/{kind}/{count}", `{"kind": "KIND_A", "count": 3}`, "/v1/KIND_A/3", []string{"kind", "count"}},
+		{"/v1/{tags}", `{"tags": ["a", "b"]}`, "/v1/a%2Cb", []string{"tags"}},
+		{"/v1/{data}", `{"data": "+/8="}`, "/v1/-_8=", []string{"data"}},
+		{"/v1/{time}", `{"time": "2023-01-02T03:04:05Z"}`, "/v1/2023-01-02T03:04:05Z", []string{"time"}},
+	} {
+		path, fieldPaths, err := expandTemplate(tc.template, newItem(t, tc.item))
+		if err != nil {
+			t.Errorf("%s: %v", tc.template, err)
+			continue
+		}
+		if path != tc.path || strings.Join(fieldPaths, ",") != strings.Join(tc.fieldPaths, ",") {
+			t.Errorf("%s: expected %s %v, got %s %v", tc.template, tc.path, tc.fieldPaths, path, fieldPaths)
+		}
+	}
+
+	for _, tc := range []struct {
+		template, err string
+	}{
+		{"/v1/{name", `invalid path template "/v1/{name"`},
+		{"/v1/{name}", `path parameter "name" is empty`},
+		{"/v1/{missing}", `no field "missing" in rest.test.Item`},
+		{"/v1/{tags.name}", `field "tags" in rest.test.Item is not a singular message field`},
+		{"/v1/{parent}", `message field "rest.test.Item.parent" cannot be used as a parameter`},
+	} {
+		m := newItem(t, `{"parent": {"name": "a"}}`)
+		if _, _, err := expandTemplate(tc.template, m); err == nil || err.Error() != tc.err {
+			t.Errorf("%s: expected error %q, got %v", tc.template, tc.err, err)
+		}
+	}
+}
+
+func TestQueryParams(t *testing.T) {
+	m := newItem(t, `{
+		"name": "a",
+		"count": 3,
+		"kind": "KIND_A",
+		"data": "AQI=",
+		"tags": ["x", "y"],
+		"labels": {"k": 1},
+		"parent": {"name": "p", "parent": {"count": 1}},
+		"time": "2023-01-02T03:04:05Z"
+	}`)
+	query := url.Values{}
+	if err := queryParams(m.ProtoReflect(), "", map[string]bool{"name": true, "parent.name": true}, query); err != nil {
+		t.Fatal(err)
+	}
+	expected := url.Values{
+		"count":               {"3"},
+		"kind":                {"KIND_A"},
+		"data":                {"AQI="},
+		"tags":                {"x", "y"},
+		"labels[k]":           {"1"},
+		"parent.parent.count": {"1"},
+		"time":                {"2023-01-02T03:04:05Z"},
+	}
+	if query.Encode() != expected.Encode() {
+		t.Fatalf("expected %s, got %s", expected.Encode(), query.Encode())
+	}
+}
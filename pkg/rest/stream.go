@@ -0,0 +1,131 @@
+package rest
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+
+	spb "google.golang.org/genproto/googleapis/rpc/status"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+)
+
+// ServerStream reads a server streaming response from grpc-gateway, which is
+// written as a sequence of {"result": ...} or {"error": ...} JSON objects.
+// It implements grpc.ClientStream, so it can be used as the stream type of
+// generated server streaming methods.
+type ServerStream[T any, PT interface {
+	*T
+	proto.Message
+}] struct {
+	ctx     context.Context
+	binding *Binding
+	resp    *http.Response
+	dec     *json.Decoder
+	err     error
+}
+
+var _ grpc.ClientStream = (*ServerStream[spb.Status, *spb.Status])(nil)
+
+// NewServerStream sends a server streaming request.
+func NewServerStream[T any, PT interface {
+	*T
+	proto.Message
+}](ctx context.Context, c *Client, b *Binding, in proto.Message, opts ...grpc.CallOption) (*ServerStream[T, PT], error) {
+	resp, err := c.do(ctx, b, in, opts)
+	if err != nil {
+		return nil, err
+	}
+	return &ServerStream[T, PT]{
+		ctx:     ctx,
+		binding: b,
+		resp:    resp,
+		dec:     json.NewDecoder(resp.Body),
+	}, nil
+}
+
+// Recv returns the next message in the stream, or io.EOF when the stream
+// has ended.
+func (s *ServerStream[T, PT]) Recv() (*T, error) {
+	if s.err != nil {
+		return nil, s.err
+	}
+	var chunk struct {
+		Result json.RawMessage `json:"result"`
+		Error  json.RawMessage `json:"error"`
+	}
+	if err := s.dec.Decode(&chunk); err != nil {
+		s.resp.Body.Close()
+		switch {
+		case errors.Is(err, io.EOF):
+			s.err = io.EOF
+		case s.ctx.Err() != nil:
+			s.err = status.FromContextError(s.ctx.Err()).Err()
+		default:
+			s.err = status.Errorf(codes.Internal, "failed to read stream: %v", err)
+		}
+		return nil, s.err
+	}
+	if chunk.Error != nil {
+		s.resp.Body.Close()
+		st := &spb.Status{}
+		if err := unmarshalOptions.Unmarshal(chunk.Error, st); err != nil {
+			s.err = status.Errorf(codes.Unknown, "failed to unmarshal stream error: %v", err)
+		} else {
+			s.err = status.ErrorProto(st)
+		}
+		return nil, s.err
+	}
+	msg := PT(new(T))
+	if err := unmarshalResponse(s.binding, chunk.Result, msg); err != nil {
+		s.resp.Body.Close()
+		s.err = status.Errorf(codes.Internal, "failed to unmarshal response: %v", err)
+		return nil, s.err
+	}
+	return (*T)(msg), nil
+}
+
+// Header returns the header metadata received from the server.
+func (s *ServerStream[T, PT]) Header() (metadata.MD, error) {
+	return headerMetadata(s.resp.Header, metadataHeaderPrefix), nil
+}
+
+// Trailer returns the trailer metadata received from the server. It is only
+// available after Recv has returned an error, including io.EOF.
+func (s *ServerStream[T, PT]) Trailer() metadata.MD {
+	return headerMetadata(s.resp.Trailer, metadataTrailerPrefix)
+}
+
+// CloseSend does nothing, as the request has already been sent.
+func (s *ServerStream[T, PT]) CloseSend() error {
+	return nil
+}
+
+func (s *ServerStream[T, PT]) Context() context.Context {
+	return s.ctx
+}
+
+// SendMsg always returns an error, as server streaming requests are sent
+// in full when the stream is created.
+func (s *ServerStream[T, PT]) SendMsg(any) error {
+	return status.Error(codes.Internal, "SendMsg is not supported by server streams")
+}
+
+// RecvMsg receives the next message into m, which must be of type *T.
+func (s *ServerStream[T, PT]) RecvMsg(m any) error {
+	dst, ok := m.(PT)
+	if !ok {
+		return status.Errorf(codes.Internal, "unexpected message type %T", m)
+	}
+	msg, err := s.Recv()
+	if err != nil {
+		return err
+	}
+	proto.Merge(dst, PT(msg))
+	return nil
+}
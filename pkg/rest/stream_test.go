@@ -0,0 +1,123 @@
+package rest
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/wrapperspb"
+)
+
+func newStreamServer(t *testing.T, chunks ...string) *Client {
+	t.Helper()
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Grpc-Metadata-Header", "h")
+		w.Header().Set("Trailer", "Grpc-Trailer-Trailer")
+		for _, chunk := range chunks {
+			io.WriteString(w, chunk+"\n")
+			w.(http.Flusher).Flush()
+		}
+		w.Header().Set("Grpc-Trailer-Trailer", "t")
+	}))
+	t.Cleanup(srv.Close)
+	return NewClient(srv.URL, srv.Client())
+}
+
+func TestServerStream(t *testing.T) {
+	c := newStreamServer(t, `{"result": "a"}`, `{"result": "b"}`)
+	stream, err := NewServerStream[wrapperspb.StringValue](context.Background(), c, &Binding{Method: "GET", Template: "/watch"}, &wrapperspb.StringValue{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if md, _ := stream.Header(); md.Get("header")[0] != "h" {
+		t.Fatalf("unexpected header: %v", md)
+	}
+	msg, err := stream.Recv()
+	if err != nil || msg.Value != "a" {
+		t.Fatalf("Recv: %v, %v", msg, err)
+	}
+	received := &wrapperspb.StringValue{}
+	if err := stream.RecvMsg(received); err != nil || received.Value != "b" {
+		t.Fatalf("RecvMsg: %v, %v", received, err)
+	}
+	for i := 0; i < 2; i++ {
+		if _, err := stream.Recv(); err != io.EOF {
+			t.Fatalf("expected io.EOF, got %v", err)
+		}
+	}
+	if md := stream.Trailer(); md.Get("trailer")[0] != "t" {
+		t.Fatalf("unexpected trailer: %v", md)
+	}
+	if status.Code(stream.SendMsg(received)) != codes.Internal {
+		t.Fatal("expected SendMsg to fail")
+	}
+}
+
+func TestServerStreamError(t *testing.T) {
+	c := newStreamServer(t, `{"result": "a"}`, `{"error": {"code": 14, "message": "going away"}}`, `{"result": "b"}`)
+	stream, err := NewServerStream[wrapperspb.StringValue](context.Background(), c, &Binding{Method: "GET", Template: "/watch"}, &wrapperspb.StringValue{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if msg, err := stream.Recv(); err != nil || msg.Value != "a" {
+		t.Fatalf("Recv: %v, %v", msg, err)
+	}
+	// the error ends the stream
+	for i := 0; i < 2; i++ {
+		if _, err := stream.Recv(); status.Code(err) != codes.Unavailable || status.Convert(err).Message() != "going away" {
+			t.Fatalf("expected the stream error, got %v", err)
+		}
+	}
+}
+
+func TestServerStreamInvalid(t *testing.T) {
+	c := newStreamServer(t, `{"result": 1}`)
+	stream, err := NewServerStream[wrapperspb.StringValue](context.Background(), c, &Binding{Method: "GET", Template: "/watch"}, &wrapperspb.StringValue{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := stream.Recv(); status.Code(err) != codes.Internal {
+		t.Fatalf("expected codes.Internal, got %v", err)
+	}
+}
+
+func TestServerStreamCanceled(t *testing.T) {
+	started := make(chan struct{})
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		io.WriteString(w, `{"result": "a"}`+"\n")
+		w.(http.Flusher).Flush()
+		close(started)
+		<-r.Context().Done()
+	}))
+	defer srv.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	stream, err := NewServerStream[wrapperspb.StringValue](ctx, NewClient(srv.URL, srv.Client()), &Binding{Method: "GET", Template: "/watch"}, &wrapperspb.StringValue{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := stream.Recv(); err != nil {
+		t.Fatal(err)
+	}
+	<-started
+	cancel()
+	if _, err := stream.Recv(); status.Code(err) != codes.Canceled {
+		t.Fatalf("expected codes.Canceled, got %v", err)
+	}
+}
+
+func TestServerStreamHTTPError(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusForbidden)
+		io.WriteString(w, `{"code": 7, "message": "denied"}`)
+	}))
+	defer srv.Close()
+	_, err := NewServerStream[wrapperspb.StringValue](context.Background(), NewClient(srv.URL, srv.Client()), &Binding{Method: "GET", Template: "/watch"}, &wrapperspb.StringValue{})
+	if status.Code(err) != codes.PermissionDenied {
+		t.Fatalf("expected codes.PermissionDenied, got %v", err)
+	}
+}
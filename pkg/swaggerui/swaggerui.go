@@ -0,0 +1,83 @@
+// Package swaggerui serves OpenAPI definitions along with a Swagger UI page,
+// using the Swagger UI assets embedded in github.com/swaggo/files. It is used
+// by code generated by the go-grpc-gateway generator when the swagger_ui
+// option is set.
+package swaggerui
+
+import (
+	"html/template"
+	"net/http"
+	"path"
+	"strings"
+
+	swaggerFiles "github.com/swaggo/files/v2"
+)
+
+var indexTemplate = template.Must(template.New("index.html").Parse(`<!DOCTYPE html>
+<html lang="en">
+  <head>
+    <meta charset="UTF-8">
+    <title>{{ .Title }}</title>
+    <link rel="stylesheet" type="text/css" href="./swagger-ui.css" />
+    <link rel="stylesheet" type="text/css" href="./index.css" />
+    <link rel="icon" type="image/png" href="./favicon-32x32.png" sizes="32x32" />
+    <link rel="icon" type="image/png" href="./favicon-16x16.png" sizes="16x16" />
+  </head>
+  <body>
+    <div id="swagger-ui"></div>
+    <script src="./swagger-ui-bundle.js" charset="UTF-8"></script>
+    <script src="./swagger-ui-standalone-preset.js" charset="UTF-8"></script>
+    <script>
+      window.onload = function() {
+        window.ui = SwaggerUIBundle({
+          url: {{ .SpecURL }},
+          dom_id: "#swagger-ui",
+          deepLinking: true,
+          presets: [SwaggerUIBundle.presets.apis, SwaggerUIStandalonePreset],
+          plugins: [SwaggerUIBundle.plugins.DownloadUrl],
+          layout: "StandaloneLayout"
+        });
+      };
+    </script>
+  </body>
+</html>
+`))
+
+// Handler returns an http.Handler which serves the spec under the given
+// file name (e.g. "foo.swagger.json") and a Swagger UI page for it. The
+// handler only uses the last element of the request path, so it can be
+// mounted under any path prefix; the UI page is served for paths ending
+// in "/", and requests for the prefix itself are redirected there.
+func Handler(spec []byte, filename string) http.Handler {
+	assets := http.FileServer(http.FS(swaggerFiles.FS))
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		name := path.Base(r.URL.Path)
+		switch {
+		case strings.HasSuffix(r.URL.Path, "/") || name == "index.html":
+			w.Header().Set("Content-Type", "text/html; charset=utf-8")
+			indexTemplate.Execute(w, map[string]string{
+				"Title":   filename,
+				"SpecURL": "./" + filename,
+			})
+		case name == filename:
+			ServeSpec(w, spec, filename)
+		case path.Ext(name) == "":
+			http.Redirect(w, r, r.URL.Path+"/", http.StatusMovedPermanently)
+		default:
+			r = r.Clone(r.Context())
+			r.URL.Path = "/" + name
+			assets.ServeHTTP(w, r)
+		}
+	})
+}
+
+// ServeSpec writes the spec with a content type based on the extension of
+// the file name.
+func ServeSpec(w http.ResponseWriter, spec []byte, filename string) {
+	if path.Ext(filename) == ".yaml" {
+		w.Header().Set("Content-Type", "application/yaml")
+	} else {
+		w.Header().Set("Content-Type", "application/json")
+	}
+	w.Write(spec)
+}
@@ -1,5 +1,7 @@
 package util
 
+import "strings"
+
 func Map[T any, R any](collection []T, iteratee func(T) R) []R {
 	result := make([]R, len(collection))
 
@@ -9,3 +11,28 @@ func Map[T any, R any](collection []T, iteratee func(T) R) []R {
 
 	return result
 }
+
+// ParsePluginParams splits a protoc-style parameter string (a comma-separated
+// list of key=value pairs) and calls fn for each parameter. Parameters that
+// are handled by protogen itself (paths, module, annotate_code, and M<file>
+// import path mappings) are skipped.
+func ParsePluginParams(param string, fn func(name, value string) error) error {
+	for _, p := range strings.Split(param, ",") {
+		var value string
+		if i := strings.Index(p, "="); i >= 0 {
+			value = p[i+1:]
+			p = p[:i]
+		}
+		switch p {
+		case "", "module", "paths", "annotate_code":
+			continue
+		}
+		if p[0] == 'M' {
+			continue
+		}
+		if err := fn(p, value); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -35,7 +35,12 @@ func (g *GeneratedFile) Read(p []byte) (int, error) {
 	return copy(p, g.Content), nil
 }
 
+// WriteToDisk writes the file to SourceRelPath, creating any missing parent
+// directories (e.g. for code generated into a subpackage).
 func (g *GeneratedFile) WriteToDisk() error {
+	if err := os.MkdirAll(filepath.Dir(g.SourceRelPath), 0755); err != nil {
+		return err
+	}
 	return os.WriteFile(g.SourceRelPath, []byte(g.Content), 0644)
 }
 
@@ -66,6 +71,9 @@ func GenerateCode(generators []Generator, sources ...string) (_ []*GeneratedFile
 		sources = resolved
 	}
 
+	// source files are parsed in the order they were given, so that the
+	// generated files are too
+	var sourceNames []string
 	sourcePackages := map[string]string{}
 	sourcePkgDirs := map[string]string{}
 	for _, source := range sources {
@@ -74,10 +82,14 @@ func GenerateCode(generators []Generator, sources ...string) (_ []*GeneratedFile
 			return nil, fmt.Errorf("failed to lookup go module for %s: %w", source, err)
 		}
 		sourcePkgDirs[goPkg] = filepath.Dir(source)
-		sourcePackages[path.Join(goPkg, path.Base(source))] = source
+		name := path.Join(goPkg, path.Base(source))
+		if _, ok := sourcePackages[name]; !ok {
+			sourceNames = append(sourceNames, name)
+		}
+		sourcePackages[name] = source
 	}
 
-	sourceDescriptors, err := ParseFiles(SourceAccessor(sourcePackages), lo.Keys(sourcePackages)...)
+	sourceDescriptors, err := ParseFiles(SourceAccessor(sourcePackages), sourceNames...)
 	if err != nil {
 		return nil, err
 	}
@@ -95,36 +107,68 @@ func GenerateCode(generators []Generator, sources ...string) (_ []*GeneratedFile
 		}
 	}
 
-	codeGeneratorRequest := &pluginpb.CodeGeneratorRequest{
-		FileToGenerate: util.Map(sourceDescriptors, (*desc.FileDescriptor).GetName),
-		ProtoFile:      allDescriptors,
-		CompilerVersion: &pluginpb.Version{
-			Major: lo.ToPtr[int32](1),
-			Minor: lo.ToPtr[int32](0),
-			Patch: lo.ToPtr[int32](0),
-		},
-	}
-	plugin, err := (protogen.Options{}).New(codeGeneratorRequest)
-	if err != nil {
-		return nil, err
+	newPlugin := func(param string) (*protogen.Plugin, error) {
+		codeGeneratorRequest := &pluginpb.CodeGeneratorRequest{
+			FileToGenerate: util.Map(sourceDescriptors, (*desc.FileDescriptor).GetName),
+			ProtoFile:      allDescriptors,
+			CompilerVersion: &pluginpb.Version{
+				Major: lo.ToPtr[int32](1),
+				Minor: lo.ToPtr[int32](0),
+				Patch: lo.ToPtr[int32](0),
+			},
+		}
+		if param != "" {
+			codeGeneratorRequest.Parameter = &param
+		}
+		return (protogen.Options{}).New(codeGeneratorRequest)
 	}
 
+	// generators sharing the same parameter string share a plugin instance
+	var plugins []*protogen.Plugin
+	pluginsByParam := map[string]*protogen.Plugin{}
 	for _, g := range generators {
+		var param string
+		if pg, ok := g.(ParameterizedGenerator); ok {
+			param = pg.Parameter()
+		}
+		plugin, ok := pluginsByParam[param]
+		if !ok {
+			plugin, err = newPlugin(param)
+			if err != nil {
+				return nil, fmt.Errorf("generator %s: %w", g.Name(), err)
+			}
+			pluginsByParam[param] = plugin
+			plugins = append(plugins, plugin)
+		}
 		if err := g.Generate(plugin); err != nil {
 			return nil, err
 		}
 	}
 
-	response := plugin.Response()
-	if response.Error != nil {
-		return nil, errors.New(response.GetError())
+	var responseFiles []*pluginpb.CodeGeneratorResponse_File
+	for _, plugin := range plugins {
+		response := plugin.Response()
+		if response.Error != nil {
+			return nil, errors.New(response.GetError())
+		}
+		responseFiles = append(responseFiles, response.GetFile()...)
 	}
 
 	var outputs []*GeneratedFile
-	for _, f := range response.GetFile() {
+	for _, f := range responseFiles {
 		pkg, name := filepath.Split(f.GetName())
 		pkg = strings.TrimSuffix(pkg, "/")
 		dir, ok := sourcePkgDirs[pkg]
+		if !ok {
+			// files generated into a subpackage of a source package are placed
+			// in the corresponding subdirectory
+			for parent := path.Dir(pkg); parent != "." && parent != "/"; parent = path.Dir(parent) {
+				if parentDir, found := sourcePkgDirs[parent]; found {
+					dir, ok = path.Join(parentDir, strings.TrimPrefix(pkg, parent)), true
+					break
+				}
+			}
+		}
 		if !ok {
 			if strings.Contains(pkg, "google/") {
 				dir = pkg[strings.Index(pkg, "google/"):]
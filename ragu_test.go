@@ -9,7 +9,7 @@ import (
 )
 
 func TestGenerateCode(t *testing.T) {
-	out, err := ragu.GenerateCode(ragu.AllGenerators(), "**/*.proto")
+	out, err := ragu.GenerateCode(ragu.AllGenerators(), "testdata/**/*.proto")
 	if err != nil {
 		t.Fatal(err)
 	}
@@ -24,7 +24,7 @@ func TestExternal(t *testing.T) {
 	out, err := ragu.GenerateCode([]ragu.Generator{
 		golang.Generator,
 		external.NewGenerator([]string{"npx", "protoc-gen-es"}, external.GeneratorOptions{Opt: "target=ts"}),
-	}, "**/*.proto")
+	}, "testdata/**/*.proto")
 	if err != nil {
 		t.Fatal(err)
 	}
@@ -37,7 +37,7 @@ func TestExternal(t *testing.T) {
 	out, err = ragu.GenerateCode([]ragu.Generator{
 		golang.Generator,
 		external.NewGenerator("protoc-gen-es", external.GeneratorOptions{Opt: "target=ts"}),
-	}, "**/*.proto")
+	}, "testdata/**/*.proto")
 	if err != nil {
 		t.Fatal(err)
 	}
@@ -47,3 +47,17 @@ func TestExternal(t *testing.T) {
 		}
 	}
 }
+
+func TestGeneratorByName(t *testing.T) {
+	for _, g := range ragu.AllGenerators() {
+		if found, ok := ragu.GeneratorByName(g.Name()); !ok || found.Name() != g.Name() {
+			t.Fatalf("generator %s is not registered", g.Name())
+		}
+	}
+	if _, ok := ragu.GeneratorByName("go-grpc-gateway"); !ok {
+		t.Fatal("expected go-grpc-gateway to be registered")
+	}
+	if _, err := ragu.GeneratorsByName("go", "nonexistent"); err == nil {
+		t.Fatal("expected an error for an unknown generator")
+	}
+}